@@ -4,10 +4,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"math/bits"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 var (
@@ -21,20 +24,38 @@ var (
 	V1_BEACON_HEADERS_ENDPOINT                    = "/eth/v1/beacon/headers/%d"
 	V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT = "/eth/v1/beacon/states/%d/finality_checkpoints"
 	V1_BEACON_STATE_COMMITTEES_ENDPOINT           = "/eth/v1/beacon/states/%d/committees"
+	V1_BEACON_STATE_ACTIVE_VALIDATORS_ENDPOINT    = "/eth/v1/beacon/states/%d/validators?status=active"
 	V1_BEACON_BLOCKS_ATTESTATIONS_ENDPOINT        = "/eth/v1/beacon/blocks/%d/attestations"
+	V1_EVENTS_ENDPOINT                            = "/eth/v1/events?topics=%s"
+	V1_VALIDATOR_DUTIES_PROPOSER_ENDPOINT         = "/eth/v1/validator/duties/proposer/%d"
+
+	// Event Stream Topics
+	HEAD_TOPIC                 = "head"
+	BLOCK_TOPIC                = "block"
+	FINALIZED_CHECKPOINT_TOPIC = "finalized_checkpoint"
+	CHAIN_REORG_TOPIC          = "chain_reorg"
 
 	// Client Specific Endpoints
 	LIGHTHOUSE_GLOBAL_VALIDATOR_INCLUSION = "/lighthouse/validator_inclusion/%d/global"
+	PRYSM_VALIDATOR_PARTICIPATION         = "/eth/v1alpha1/validators/participation?epoch=%d"
 )
 
 type Spec struct {
 	SecondsPerSlot    uint64 `json:"SECONDS_PER_SLOT,string"`
 	SlotsPerEpoch     uint64 `json:"SLOTS_PER_EPOCH,string"`
 	SyncCommitteeSize uint64 `json:"SYNC_COMMITTEE_SIZE,string"`
+
+	// Merge related
+	TerminalTotalDifficulty          TTD         `json:"TERMINAL_TOTAL_DIFFICULTY"`
+	TerminalBlockHash                common.Hash `json:"TERMINAL_BLOCK_HASH"`
+	TerminalBlockHashActivationEpoch uint64      `json:"TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH,string"`
+	BellatrixForkEpoch               uint64      `json:"BELLATRIX_FORK_EPOCH,string"`
 }
 
 type GenesisResponse struct {
-	GenesisTime uint64 `json:"genesis_time,string"`
+	GenesisTime           uint64      `json:"genesis_time,string"`
+	GenesisValidatorsRoot common.Hash `json:"genesis_validators_root"`
+	GenesisForkVersion    string      `json:"genesis_fork_version"`
 }
 
 type AggregationBits []byte
@@ -64,6 +85,24 @@ func (ab AggregationBits) CountSetBits() uint64 {
 	return count
 }
 
+// Count the set bits of a bitlist, excluding the highest set bit which marks the length of the
+// bitlist
+func (ab AggregationBits) CountSetBitlistBits() uint64 {
+	count := ab.CountSetBits()
+	if count > 0 {
+		count--
+	}
+	return count
+}
+
+// Whether the bit at the given index is set, bits are little-endian ordered within each byte
+func (ab AggregationBits) BitAt(index uint64) bool {
+	if index/8 >= uint64(len(ab)) {
+		return false
+	}
+	return ab[index/8]&(1<<(index%8)) != 0
+}
+
 type SyncCommitteeSignature [96]byte
 
 func (scs *SyncCommitteeSignature) UnmarshalJSON(b []byte) error {
@@ -97,10 +136,79 @@ type BeaconEth1Data struct {
 	BlockHash    common.Hash `json:"block_hash"`
 }
 
+// Uint256 is a big integer encoded as a decimal string
+type Uint256 struct {
+	*big.Int
+}
+
+func (u *Uint256) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	dec, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return fmt.Errorf("Uint256: invalid decimal string: %s", str)
+	}
+	u.Int = dec
+	return nil
+}
+
+type ExecutionPayload struct {
+	ParentHash    common.Hash     `json:"parent_hash"`
+	FeeRecipient  common.Address  `json:"fee_recipient"`
+	StateRoot     common.Hash     `json:"state_root"`
+	ReceiptsRoot  common.Hash     `json:"receipts_root"`
+	LogsBloom     hexutil.Bytes   `json:"logs_bloom"`
+	PrevRandao    common.Hash     `json:"prev_randao"`
+	BlockNumber   uint64          `json:"block_number,string"`
+	GasLimit      uint64          `json:"gas_limit,string"`
+	GasUsed       uint64          `json:"gas_used,string"`
+	Timestamp     uint64          `json:"timestamp,string"`
+	ExtraData     hexutil.Bytes   `json:"extra_data"`
+	BaseFeePerGas Uint256         `json:"base_fee_per_gas"`
+	BlockHash     common.Hash     `json:"block_hash"`
+	Transactions  []hexutil.Bytes `json:"transactions"`
+}
+
+// Whether the payload is the default payload included in blocks before the transition
+func (p *ExecutionPayload) IsDefault() bool {
+	return p == nil || p.BlockHash == (common.Hash{})
+}
+
+// Compare the execution payload with the header of the same block returned by an execution client,
+// and return the mismatching fields
+func CompareExecutionPayload(payload *ExecutionPayload, header *types.Header) []string {
+	mismatches := make([]string, 0)
+	if payload.BlockHash != header.Hash() {
+		mismatches = append(mismatches, fmt.Sprintf("block_hash: %s != %s", payload.BlockHash, header.Hash()))
+	}
+	if payload.BlockNumber != header.Number.Uint64() {
+		mismatches = append(mismatches, fmt.Sprintf("block_number: %d != %d", payload.BlockNumber, header.Number.Uint64()))
+	}
+	if payload.ParentHash != header.ParentHash {
+		mismatches = append(mismatches, fmt.Sprintf("parent_hash: %s != %s", payload.ParentHash, header.ParentHash))
+	}
+	if payload.GasUsed != header.GasUsed {
+		mismatches = append(mismatches, fmt.Sprintf("gas_used: %d != %d", payload.GasUsed, header.GasUsed))
+	}
+	if payload.BaseFeePerGas.Int == nil || header.BaseFee == nil || payload.BaseFeePerGas.Cmp(header.BaseFee) != 0 {
+		mismatches = append(mismatches, fmt.Sprintf("base_fee_per_gas: %v != %v", payload.BaseFeePerGas.Int, header.BaseFee))
+	}
+	if payload.Timestamp != header.Time {
+		mismatches = append(mismatches, fmt.Sprintf("timestamp: %d != %d", payload.Timestamp, header.Time))
+	}
+	if payload.PrevRandao != header.MixDigest {
+		mismatches = append(mismatches, fmt.Sprintf("prev_randao: %s != %s", payload.PrevRandao, header.MixDigest))
+	}
+	return mismatches
+}
+
 type BeaconBlockBody struct {
-	BeaconEth1Data BeaconEth1Data      `json:"eth1_data"`
-	Attestations   []Attestation       `json:"attestations"`
-	SyncAggregate  BeaconSyncAggregate `json:"sync_aggregate"`
+	BeaconEth1Data   BeaconEth1Data      `json:"eth1_data"`
+	Attestations     []Attestation       `json:"attestations"`
+	SyncAggregate    BeaconSyncAggregate `json:"sync_aggregate"`
+	ExecutionPayload *ExecutionPayload   `json:"execution_payload"`
 }
 
 type BeaconBlockMessage struct {
@@ -145,7 +253,22 @@ type StateFinalityCheckpoints struct {
 	Justified         FinalityCheckpoint `json:"current_justified"`
 	Finalized         FinalityCheckpoint `json:"finalized"`
 }
+
+type ProposerDuty struct {
+	Pubkey         string `json:"pubkey"`
+	ValidatorIndex uint64 `json:"validator_index,string"`
+	Slot           uint64 `json:"slot,string"`
+}
+
 type Validators []uint64
+type ValidatorInfo struct {
+	EffectiveBalance uint64 `json:"effective_balance,string"`
+}
+type ValidatorResponse struct {
+	Index     uint64        `json:"index,string"`
+	Status    string        `json:"status"`
+	Validator ValidatorInfo `json:"validator"`
+}
 type Committee struct {
 	Slot       uint64     `json:"slot,string"`
 	Index      uint64     `json:"index,string"`
@@ -187,10 +310,53 @@ func (v *Validators) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// Event Stream Structs
+
+type HeadEvent struct {
+	Slot            uint64      `json:"slot,string"`
+	Block           common.Hash `json:"block"`
+	State           common.Hash `json:"state"`
+	EpochTransition bool        `json:"epoch_transition"`
+}
+
+type BlockEvent struct {
+	Slot  uint64      `json:"slot,string"`
+	Block common.Hash `json:"block"`
+}
+
+type FinalizedCheckpointEvent struct {
+	Block common.Hash `json:"block"`
+	State common.Hash `json:"state"`
+	Epoch uint64      `json:"epoch,string"`
+}
+
+type ChainReorgEvent struct {
+	Slot         uint64      `json:"slot,string"`
+	Depth        uint64      `json:"depth,string"`
+	OldHeadBlock common.Hash `json:"old_head_block"`
+	NewHeadBlock common.Hash `json:"new_head_block"`
+	Epoch        uint64      `json:"epoch,string"`
+}
+
 // Client Specific Structs
 
 type ValidatorInclusionGlobal struct {
-	PreviousEpochActiveGwei          uint64 `json:"previous_epoch_active_gwei"`
-	PreviousEpochTargetAttestingGwei uint64 `json:"previous_epoch_target_attesting_gwei"`
-	PreviousEpochHeadAttestingGwei   uint64 `json:"previous_epoch_head_attesting_gwei"`
+	PreviousEpochActiveGwei uint64 `json:"previous_epoch_active_gwei"`
+	// Not reported by Lighthouse since Altair
+	PreviousEpochAttestingGwei       *uint64 `json:"previous_epoch_attesting_gwei"`
+	PreviousEpochTargetAttestingGwei uint64  `json:"previous_epoch_target_attesting_gwei"`
+	PreviousEpochHeadAttestingGwei   uint64  `json:"previous_epoch_head_attesting_gwei"`
+}
+
+type PrysmValidatorParticipation struct {
+	PreviousEpochActiveGwei          uint64 `json:"previous_epoch_active_gwei,string"`
+	PreviousEpochAttestingGwei       uint64 `json:"previous_epoch_attesting_gwei,string"`
+	PreviousEpochTargetAttestingGwei uint64 `json:"previous_epoch_target_attesting_gwei,string"`
+	PreviousEpochHeadAttestingGwei   uint64 `json:"previous_epoch_head_attesting_gwei,string"`
+}
+
+type PrysmValidatorParticipationResponse struct {
+	Epoch         uint64                      `json:"epoch,string"`
+	Finalized     bool                        `json:"finalized"`
+	Participation PrysmValidatorParticipation `json:"participation"`
 }
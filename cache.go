@@ -0,0 +1,71 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+var (
+	// Number of decoded responses kept per client
+	DefaultDataCacheSize = 1024
+)
+
+type dataCacheEntry struct {
+	key   string
+	value interface{}
+}
+
+// DataCache keeps the most recently used decoded responses of a client, so the data of a
+// block/slot is fetched only once for all the metrics that require it.
+type DataCache struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	l       sync.Mutex
+}
+
+func NewDataCache(size int) *DataCache {
+	return &DataCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *DataCache) Get(key string) (interface{}, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*dataCacheEntry).value, true
+	}
+	return nil, false
+}
+
+func (c *DataCache) Add(key string, value interface{}) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*dataCacheEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&dataCacheEntry{
+		key:   key,
+		value: value,
+	})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*dataCacheEntry).key)
+	}
+}
+
+func (c *DataCache) Remove(key string) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+		delete(c.entries, key)
+	}
+}
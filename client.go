@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"sync"
 )
 
 type Client interface {
@@ -12,11 +13,28 @@ type Client interface {
 	// Get the latest block/slot number for this client
 	GetLatestBlockSlotNumber() (uint64, error)
 
+	// Pre-fetch the data of a range of blocks/slots while catching up:
+	//   Execution clients will fetch the headers in batches
+	//   Consensus clients this is a no-op
+	PrefetchDataPoints(fromBlockSlot uint64, toBlockSlot uint64) error
+
 	// Update the TTD Block information:
 	//   Execution clients will ask for the totalDifficulty and find the TTD block
 	//   Consensus clients this is a no-op
 	UpdateGetTTDBlockSlot() (*uint64, error)
 
+	// Get the outcomes of the verifications performed by the client on its own:
+	//   Execution clients verify the terminal block
+	//   Consensus clients cross-check the execution and beacon transition detections
+	ClientVerifications() []ClientVerification
+
+	// Subscribe to the notifications sent each time the client learns about new data
+	SubscribeNewData() <-chan interface{}
+
+	// Whether the client is currently receiving new data notifications from the node,
+	// otherwise the client must be polled
+	IsSubscribed() bool
+
 	// Get the client type: Execution or Beacon
 	ClientLayer() ClientLayer
 
@@ -29,6 +47,9 @@ type Client interface {
 	// Get the client ID
 	ClientID() int
 
+	// Get the node the client belongs to, nil if the client is not paired
+	Node() *Node
+
 	// Get the client version if available
 	String() string
 
@@ -38,6 +59,52 @@ type Client interface {
 
 type Clients []Client
 
+// Get the log context identifying a client
+func ClientLogCtx(cl Client) []interface{} {
+	ctx := []interface{}{"client", cl.ClientType(), "clientID", cl.ClientID()}
+	if node := cl.Node(); node != nil {
+		ctx = append(ctx, "node", node)
+	}
+	return ctx
+}
+
+// Notifier fans out a notification to all its subscribers without blocking
+type Notifier struct {
+	subscribers []chan interface{}
+	l           sync.Mutex
+}
+
+func (n *Notifier) Subscribe() chan interface{} {
+	n.l.Lock()
+	defer n.l.Unlock()
+	ch := make(chan interface{}, 1)
+	n.subscribers = append(n.subscribers, ch)
+	return ch
+}
+
+func (n *Notifier) Unsubscribe(ch chan interface{}) {
+	n.l.Lock()
+	defer n.l.Unlock()
+	for i, s := range n.subscribers {
+		if s == ch {
+			n.subscribers = append(n.subscribers[:i], n.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (n *Notifier) Notify() {
+	n.l.Lock()
+	defer n.l.Unlock()
+	for _, ch := range n.subscribers {
+		select {
+		case ch <- nil:
+		default:
+			// Subscriber has a pending notification already
+		}
+	}
+}
+
 func (cs *Clients) BeaconClients() []*BeaconClient {
 	beaconClients := make([]*BeaconClient, 0)
 	for _, c := range *cs {
@@ -72,17 +139,20 @@ func (cs *Clients) Set(typeUrl string) error {
 	if len(splitUrl) != 2 {
 		return fmt.Errorf("invalid format")
 	}
-	clientTypeStr := splitUrl[0]
-	url := splitUrl[1]
+	_, err := cs.AddClient(splitUrl[0], splitUrl[1])
+	return err
+}
 
+// Instantiate a client of the given type and append it to the list
+func (cs *Clients) AddClient(clientTypeStr string, url string) (Client, error) {
 	clientType, ok := ParseClientTypeString(clientTypeStr)
 	if !ok {
-		return fmt.Errorf("invalid client type: %s", clientTypeStr)
+		return nil, fmt.Errorf("invalid client type: %s", clientTypeStr)
 	}
 
 	ct, ok := ClientTypeToLayer[clientType]
 	if !ok {
-		return fmt.Errorf("unknown client type")
+		return nil, fmt.Errorf("unknown client type")
 	}
 
 	var clientID int
@@ -97,20 +167,20 @@ func (cs *Clients) Set(typeUrl string) error {
 	case Execution:
 		el, err := NewExecutionClient(clientType, clientID, url)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		*cs = append(*cs, el)
-		return nil
+		return el, nil
 	case Beacon:
 		bc, err := NewBeaconClient(clientType, clientID, url)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		*cs = append(*cs, bc)
-		return nil
+		return bc, nil
 	}
 
-	return fmt.Errorf("unable to instantiate client %s", clientType)
+	return nil, fmt.Errorf("unable to instantiate client %s", clientType)
 }
 
 func (cs *Clients) String() string {
@@ -6,18 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"gopkg.in/inconshreveable/log15.v2"
 )
 
-var (
-	// Info Retrieval Global Timeout
-	InfoRetrievalTimeout = 61 * time.Second
-)
-
 type BeaconClient struct {
 	Type          ClientType
 	ID            int
@@ -28,18 +24,54 @@ type BeaconClient struct {
 	// Spec config
 	Spec Spec
 
+	// Node the client belongs to, if paired with an execution client
+	node *Node
+
 	// Genesis
 	GenesisTime *uint64
 
+	// Decoded responses shared by all metrics
+	cache *DataCache
+
+	// Source of the validators' participation used by the attestation performance metrics
+	ParticipationSource ParticipationSource
+
+	// First epoch which proposer duties are requested
+	proposerDutiesStartEpoch *uint64
+	proposerDutiesLock       sync.Mutex
+	// Consecutive missed duties of each validator, as of the next slot to verify
+	proposerMisses         map[uint64]uint64
+	proposerMissesNextSlot uint64
+	proposerMissesLock     sync.Mutex
+
 	// Merge related
 	TTD           TTD
 	TTDSlotNumber *uint64
 
 	// Merge Related
-	TTDTimestamp *uint64
+	// Terminal block reported by the execution clients
+	TerminalBlock     *types.Header
+	terminalBlockLock sync.Mutex
+	// Whether an execution client reports the terminal block to this client
+	TerminalBlockExpected bool
+	// First slot which block contains a non-default execution payload
+	TransitionSlot    *uint64
+	TransitionOutcome *VerificationOutcome
+	// Time at which the transition slot was detected
+	transitionDetectedAt time.Time
+
+	// Event stream related
+	HeadSlot            *uint64
+	FinalizedCheckpoint *FinalityCheckpoint
+	subscribed          bool
+	newData             Notifier
+	eventsL             sync.Mutex
+	closeChan           chan interface{}
 
 	// Lock
 	l sync.Mutex
+	// Lock of the state updated while fetching the metrics, which are fetched concurrently
+	stateL sync.Mutex
 
 	// Context related
 	lastCtx    context.Context
@@ -58,15 +90,25 @@ func NewBeaconClient(clientType ClientType, id int, baseUrl string) (*BeaconClie
 		ID:         id,
 		BaseURL:    baseUrl,
 		HTTPClient: client,
+		cache:      NewDataCache(DefaultDataCacheSize),
+		closeChan:  make(chan interface{}),
 	}
 
+	return &cl, nil
+}
+
+// Fetch the spec of the client and start following its event stream.
+// The node is only contacted once the preflight check has reported whether it is reachable.
+func (cl *BeaconClient) Init() error {
 	var res Spec
 	if err := cl.sendRequest(GET_REQUEST, V1_CONFIG_SPEC_ENDPOINT, &res); err != nil {
-		return nil, err
+		return err
 	}
 	cl.Spec = res
 
-	return &cl, nil
+	go cl.eventStreamLoop()
+
+	return nil
 }
 
 func (cl *BeaconClient) ClientLayer() ClientLayer {
@@ -85,12 +127,9 @@ func (cl *BeaconClient) ClientVersion() (string, error) {
 	return resp.Version, nil
 }
 
-func (cl *BeaconClient) UpdateTTDTimestamp(newTimestamp uint64) {
-	timestamp := newTimestamp
-	cl.TTDTimestamp = &timestamp
-}
-
 func (cl *BeaconClient) GetGenesisTime() *uint64 {
+	cl.stateL.Lock()
+	defer cl.stateL.Unlock()
 	if cl.GenesisTime == nil {
 		res := GenesisResponse{}
 		if err := cl.sendRequest(GET_REQUEST, V1_BEACON_GENESIS_ENDPOINT, &res); err == nil {
@@ -112,6 +151,18 @@ func (cl *BeaconClient) SlotAtTime(t uint64) (uint64, error) {
 	return (t - (*genesisTime)) / cl.Spec.SecondsPerSlot, nil
 }
 
+// Get the number of seconds elapsed since the start of the slot at the given time
+func (cl *BeaconClient) SlotOffsetAtTime(t uint64) (uint64, error) {
+	genesisTime := cl.GetGenesisTime()
+	if genesisTime == nil {
+		return 0, fmt.Errorf("no genesis yet")
+	}
+	if (*genesisTime) > t {
+		return 0, fmt.Errorf("time before genesis")
+	}
+	return (t - (*genesisTime)) % cl.Spec.SecondsPerSlot, nil
+}
+
 func (cl *BeaconClient) EpochForSlot(slot uint64) uint64 {
 	return slot / cl.Spec.SlotsPerEpoch
 }
@@ -132,38 +183,95 @@ func (cl *BeaconClient) GetLatestBlockSlotNumber() (uint64, error) {
 	return cl.GetOngoingSlotNumber()
 }
 
-func (cl *BeaconClient) UpdateGetTTDBlockSlot() (*uint64, error) {
-	// We need to have the TTD block timestamp from the Execution Clients
-	if cl.TTDSlotNumber != nil {
-		return cl.TTDSlotNumber, nil
-	}
-	if cl.TTDTimestamp != nil {
-		slotAtTTD, err := cl.SlotAtTime(*cl.TTDTimestamp)
-		if err != nil {
-			return nil, err
-		}
-		cl.TTDSlotNumber = &slotAtTTD
-		return cl.TTDSlotNumber, nil
-	}
-	return nil, nil
+func (cl *BeaconClient) PrefetchDataPoints(fromSlot uint64, toSlot uint64) error {
+	return nil
 }
 
 func (cl *BeaconClient) GetBeaconHeader(slotNumber uint64) (*BeaconHeaderResponse, error) {
+	endpoint := fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, slotNumber)
+	if cached, ok := cl.cache.Get(endpoint); ok {
+		return cached.(*BeaconHeaderResponse), nil
+	}
 	var resp BeaconHeaderResponse
-	err := cl.sendRequest(GET_REQUEST, fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, slotNumber), &resp)
-	return &resp, err
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &resp); err != nil {
+		return nil, err
+	}
+	cl.cache.Add(endpoint, &resp)
+	return &resp, nil
+}
+
+func (cl *BeaconClient) GetBeaconBlock(slotNumber uint64) (*BeaconBlock, error) {
+	endpoint := fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, slotNumber)
+	if cached, ok := cl.cache.Get(endpoint); ok {
+		return cached.(*BeaconBlock), nil
+	}
+	var block BeaconBlock
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &block); err != nil {
+		return nil, err
+	}
+	cl.cache.Add(endpoint, &block)
+	return &block, nil
 }
 
 func (cl *BeaconClient) GetFinalityCheckpoints(slotNumber uint64) (*StateFinalityCheckpoints, error) {
+	endpoint := fmt.Sprintf(V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT, slotNumber)
+	if cached, ok := cl.cache.Get(endpoint); ok {
+		return cached.(*StateFinalityCheckpoints), nil
+	}
 	var resp StateFinalityCheckpoints
-	err := cl.sendRequest(GET_REQUEST, fmt.Sprintf(V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT, slotNumber), &resp)
-	return &resp, err
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &resp); err != nil {
+		return nil, err
+	}
+	cl.cache.Add(endpoint, &resp)
+	return &resp, nil
+}
+
+// Get the undecoded data of the response of an endpoint.
+// Raw responses are cached separately from the decoded ones and are not invalidated on reorgs,
+// so only endpoints of a given slot or epoch must be requested through this function, see
+// FetchRawData.
+func (cl *BeaconClient) GetRawData(endpoint string) (json.RawMessage, error) {
+	key := "raw:" + endpoint
+	if cached, ok := cl.cache.Get(key); ok {
+		return cached.(json.RawMessage), nil
+	}
+	data, err := cl.FetchRawData(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	cl.cache.Add(key, data)
+	return data, nil
+}
+
+// Get the undecoded data of the response of an endpoint, without caching it
+func (cl *BeaconClient) FetchRawData(endpoint string) (json.RawMessage, error) {
+	var data json.RawMessage
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Get the committees of all the slots of an epoch.
+// The committees are requested from the state at the start of the epoch, so the response is
+// fetched and cached once per epoch.
+func (cl *BeaconClient) GetEpochCommittees(epoch uint64) ([]Committee, error) {
+	endpoint := fmt.Sprintf(V1_BEACON_STATE_COMMITTEES_ENDPOINT, epoch*cl.Spec.SlotsPerEpoch)
+	if cached, ok := cl.cache.Get(endpoint); ok {
+		return cached.([]Committee), nil
+	}
+	var allCommittees []Committee
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &allCommittees); err != nil {
+		return nil, err
+	}
+	cl.cache.Add(endpoint, allCommittees)
+	return allCommittees, nil
 }
 
 func (cl *BeaconClient) GetSlotCommittees(slotNumber uint64) (*[]Committee, error) {
 	committees := make([]Committee, 0)
-	var allCommittees []Committee
-	if err := cl.sendRequest(GET_REQUEST, fmt.Sprintf(V1_BEACON_STATE_COMMITTEES_ENDPOINT, slotNumber), &allCommittees); err != nil {
+	allCommittees, err := cl.GetEpochCommittees(cl.EpochForSlot(slotNumber))
+	if err != nil {
 		return nil, err
 	}
 	for _, c := range allCommittees {
@@ -174,6 +282,15 @@ func (cl *BeaconClient) GetSlotCommittees(slotNumber uint64) (*[]Committee, erro
 	return &committees, nil
 }
 
+// Remove the cached responses of slots that are no longer canonical
+func (cl *BeaconClient) InvalidateSlots(fromSlot uint64, toSlot uint64) {
+	for slot := fromSlot; slot <= toSlot; slot++ {
+		cl.cache.Remove(fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, slot))
+		cl.cache.Remove(fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, slot))
+		cl.cache.Remove(fmt.Sprintf(V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT, slot))
+	}
+}
+
 func (cl *BeaconClient) GetSlotCommitteeSize(slotNumber uint64) (uint64, error) {
 	slotCommittees, err := cl.GetSlotCommittees(slotNumber)
 	if err != nil {
@@ -187,8 +304,8 @@ func (cl *BeaconClient) GetSlotCommitteeSize(slotNumber uint64) (uint64, error)
 }
 
 func (cl *BeaconClient) GetSyncParticipationCountAtSlot(blockNumber uint64) (uint64, error) {
-	var block BeaconBlock
-	if err := cl.sendRequest(GET_REQUEST, fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, blockNumber), &block); err != nil {
+	block, err := cl.GetBeaconBlock(blockNumber)
+	if err != nil {
 		return 0, err
 	}
 	return block.BlockMessage.Body.SyncAggregate.SyncCommitteeBits.CountSetBits(), nil
@@ -202,184 +319,150 @@ func (cl *BeaconClient) GetSyncParticipationPercentageAtSlot(blockNumber uint64)
 	return (syncParticipationCount * 100) / cl.Spec.SyncCommitteeSize, nil
 }
 
+// Compare the execution payload of the block at the given slot with the header returned by the
+// execution client of the node: 1 if any of the fields differ, 0 otherwise
+func (cl *BeaconClient) GetExecutionPayloadMismatchAtSlot(slotNumber uint64) (uint64, error) {
+	if cl.node == nil {
+		return 0, fmt.Errorf("client is not paired with an execution client")
+	}
+	block, err := cl.GetBeaconBlock(slotNumber)
+	if err != nil {
+		if IsNotFound(err) {
+			// Missed slot, nothing to compare
+			return 0, nil
+		}
+		return 0, err
+	}
+	payload := block.BlockMessage.Body.ExecutionPayload
+	if payload.IsDefault() {
+		return 0, nil
+	}
+	header, err := cl.node.Execution.GetHeader(payload.BlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	if mismatches := CompareExecutionPayload(payload, header); len(mismatches) > 0 {
+		log15.Warn("Execution payload mismatch", append(ClientLogCtx(cl), "slot", slotNumber, "block", payload.BlockNumber, "fields", strings.Join(mismatches, ", "))...)
+		return 1, nil
+	}
+	return 0, nil
+}
+
 func (cl *BeaconClient) GetAttestationsAtBlock(blockNumber uint64) (*[]Attestation, error) {
-	var allAttestations []Attestation
-	if err := cl.sendRequest(GET_REQUEST, fmt.Sprintf(V1_BEACON_BLOCKS_ATTESTATIONS_ENDPOINT, blockNumber), &allAttestations); err != nil {
+	block, err := cl.GetBeaconBlock(blockNumber)
+	if err != nil {
 		return nil, err
 	}
-	return &allAttestations, nil
+	return &block.BlockMessage.Body.Attestations, nil
 }
 
+// Get the number of validators of all the committees of the given slot which attested, counting
+// each validator once, from the union of all the aggregates of each committee included in the
+// blocks of the inclusion window (the following SLOTS_PER_EPOCH slots).
+// The count is only available once the inclusion window has passed.
 func (cl *BeaconClient) GetAttestationCountForSlot(slotNumber uint64) (uint64, error) {
-	timeout := time.After(InfoRetrievalTimeout)
-	lastVerifiedBlock := slotNumber
-	for {
-		latestSlot, _ := cl.GetLatestBlockSlotNumber()
-		for latestSlot > lastVerifiedBlock {
-			attBlock, err := cl.GetAttestationsAtBlock(lastVerifiedBlock + 1)
-			if err != nil {
-				break
+	ongoingSlot, err := cl.GetOngoingSlotNumber()
+	if err != nil {
+		return 0, err
+	}
+	lastInclusionSlot := slotNumber + cl.Spec.SlotsPerEpoch
+	if ongoingSlot <= lastInclusionSlot {
+		return 0, fmt.Errorf("No information available yet")
+	}
+	slotCommittees, err := cl.GetSlotCommittees(slotNumber)
+	if err != nil {
+		return 0, err
+	}
+	committees := make(map[uint64]Validators)
+	for _, c := range *slotCommittees {
+		committees[c.Index] = c.Validators
+	}
+
+	attested := make(map[uint64]bool)
+	for inclusionSlot := slotNumber + 1; inclusionSlot <= lastInclusionSlot; inclusionSlot++ {
+		attestations, err := cl.GetAttestationsAtBlock(inclusionSlot)
+		if err != nil {
+			if IsNotFound(err) {
+				// Missed slot
+				continue
 			}
-			for _, att := range *attBlock {
-				if att.Data.Slot == slotNumber {
-					// we got the attestations
-					attCount := att.AggregationBits.CountSetBits()
-					if attCount > 0 {
-						attCount -= 1
-					}
-					return attCount, nil
+			return 0, err
+		}
+		for _, att := range *attestations {
+			if att.Data.Slot != slotNumber {
+				continue
+			}
+			// Only the bits of the committee members are checked, the following bit is the
+			// length bit of the bitlist
+			for i, validator := range committees[att.Data.Index] {
+				if att.AggregationBits.BitAt(uint64(i)) {
+					attested[validator] = true
 				}
 			}
-			lastVerifiedBlock++
 		}
+	}
+	return uint64(len(attested)), nil
+}
 
-		select {
-		case <-time.After(time.Second):
-		case <-timeout:
-			return 0, fmt.Errorf("timeout waiting for attestation count")
-		}
+func (cl *BeaconClient) TimeUntilSlot(slot uint64) (time.Duration, error) {
+	genesisTime := cl.GetGenesisTime()
+	if genesisTime == nil {
+		return 0, fmt.Errorf("no genesis yet")
 	}
+	slotTime := time.Unix(int64((*genesisTime)+slot*cl.Spec.SecondsPerSlot), 0)
+	return time.Until(slotTime), nil
 }
 
-func (cl *BeaconClient) GetDataPoint(dataName MetricName, slotNumber uint64) (interface{}, error) {
-	for {
-		// We fetch information only for previous slots, not current ongoing slot
-		ongoingSlot, _ := cl.GetOngoingSlotNumber()
+// Wait until the information of the given slot can be fetched, and log each new epoch reached.
+// Only to be used by the collector of the client, see awaitSlot.
+func (cl *BeaconClient) waitForSlot(slotNumber uint64) {
+	cl.awaitSlot(slotNumber, func(ongoingSlot uint64) {
+		cl.stateL.Lock()
+		defer cl.stateL.Unlock()
 		if cl.EpochForSlot(ongoingSlot) > cl.PreviousEpoch {
 			log15.Info("New epoch reached", "client", cl.ClientType(), "clientID", cl.ClientID(), "epoch", cl.EpochForSlot(ongoingSlot))
 			cl.PreviousEpoch = cl.EpochForSlot(ongoingSlot)
 		}
-		if slotNumber < ongoingSlot {
-			break
-		}
-		time.Sleep(time.Second)
-	}
-	switch dataName {
-	case BeaconBlockCount:
-		if _, err := cl.GetBeaconHeader(slotNumber); err == nil {
-			return uint64(1), nil
-		}
-		return uint64(0), nil
-	case FinalizedEpoch:
-		// Return `1` for each Finalized root change
-		if slotNumber == 0 || (slotNumber%cl.Spec.SlotsPerEpoch) != 0 {
-			return uint64(0), nil
-		}
-
-		currentSlotFinalityCheckpoint, err := cl.GetFinalityCheckpoints(slotNumber)
-		if err != nil {
-			return nil, err
-		}
-
-		if currentSlotFinalityCheckpoint.Finalized.Root == (common.Hash{}) {
-			return uint64(0), nil
-		}
-
-		prevSlotFinalityCheckpoint, err := cl.GetFinalityCheckpoints(slotNumber - 1)
-		if err != nil {
-			return nil, err
-		}
-
-		if prevSlotFinalityCheckpoint.Finalized.Root != currentSlotFinalityCheckpoint.Finalized.Root {
-			return uint64(1), nil
-		}
-		return uint64(0), nil
-
-	case JustifiedEpoch:
-		// Return `1` for each Justified root change
-		if slotNumber == 0 || (slotNumber%cl.Spec.SlotsPerEpoch) != 0 {
-			return uint64(0), nil
-		}
-
-		currentSlotFinalityCheckpoint, err := cl.GetFinalityCheckpoints(slotNumber)
-		if err != nil {
-			return nil, err
-		}
-
-		if currentSlotFinalityCheckpoint.Justified.Root == (common.Hash{}) {
-			return uint64(0), nil
-		}
-
-		prevSlotFinalityCheckpoint, err := cl.GetFinalityCheckpoints(slotNumber - 1)
-		if err != nil {
-			return nil, err
-		}
+	})
+}
 
-		if prevSlotFinalityCheckpoint.Justified.Root != currentSlotFinalityCheckpoint.Justified.Root {
-			return uint64(1), nil
+// Wait until the information of the given slot can be fetched: the slot has already
+// passed, or the event stream announced a block for the slot or any later slot.
+// The state of the client is not modified, so it can be used from any goroutine, the
+// optional onTick function is called with the ongoing slot on each check.
+func (cl *BeaconClient) awaitSlot(slotNumber uint64, onTick func(ongoingSlot uint64)) {
+	var updates chan interface{}
+	for {
+		ongoingSlot, _ := cl.GetOngoingSlotNumber()
+		if onTick != nil {
+			onTick(ongoingSlot)
 		}
-		return uint64(0), nil
-
-	case SlotAttestations:
-		return cl.GetAttestationCountForSlot(slotNumber)
-
-	case SlotAttestationsPercentage:
-		committeeSize, err := cl.GetSlotCommitteeSize(slotNumber)
-		if err != nil {
-			return uint64(0), err
+		if slotNumber < ongoingSlot {
+			return
 		}
-		if committeeSize == 0 {
-			return committeeSize, fmt.Errorf("empty committee for slot %d", slotNumber)
+		if headSlot := cl.GetHeadSlot(); headSlot != nil && *headSlot >= slotNumber {
+			return
 		}
-
-		slotAttestations, err := cl.GetAttestationCountForSlot(slotNumber)
-		if err != nil {
-			return uint64(0), err
+		if updates == nil {
+			updates = cl.newData.Subscribe()
+			defer cl.newData.Unsubscribe(updates)
 		}
-		return (slotAttestations * 100) / committeeSize, nil
-
-	case EpochAttestationPerformance:
-		switch cl.ClientType() {
-		case Lighthouse:
-			currentEpoch, err := cl.GetOngoingEpochNumber()
-			if err != nil {
-				return nil, err
-			}
-			if cl.EpochForSlot(slotNumber) >= currentEpoch {
-				// We can only get accurate information for previous epoch
-				return nil, fmt.Errorf("No information available yet")
-			}
-
-			var resp ValidatorInclusionGlobal
-
-			err = cl.sendRequest(GET_REQUEST, fmt.Sprintf(LIGHTHOUSE_GLOBAL_VALIDATOR_INCLUSION, cl.EpochForSlot(slotNumber)), &resp)
-			if err != nil {
-				return nil, err
-			}
-			return (resp.PreviousEpochHeadAttestingGwei * 100) / resp.PreviousEpochActiveGwei, nil
-		default:
-			return nil, fmt.Errorf("Invalid client for metric")
+		nextSlotDelay, err := cl.TimeUntilSlot(ongoingSlot + 1)
+		if err != nil || nextSlotDelay <= 0 {
+			nextSlotDelay = time.Second
 		}
-	case EpochTargetAttestationPerformance:
-		switch cl.ClientType() {
-		case Lighthouse:
-			currentEpoch, err := cl.GetOngoingEpochNumber()
-			if err != nil {
-				return nil, err
-			}
-			if cl.EpochForSlot(slotNumber) >= currentEpoch {
-				// We can only get accurate information for previous epoch
-				return nil, fmt.Errorf("No information available yet")
-			}
-
-			var resp ValidatorInclusionGlobal
-
-			err = cl.sendRequest(GET_REQUEST, fmt.Sprintf(LIGHTHOUSE_GLOBAL_VALIDATOR_INCLUSION, cl.EpochForSlot(slotNumber)), &resp)
-			if err != nil {
-				return nil, err
-			}
-			return (resp.PreviousEpochTargetAttestingGwei * 100) / resp.PreviousEpochActiveGwei, nil
-		default:
-			return nil, fmt.Errorf("Invalid client for metric")
+		select {
+		case <-updates:
+		case <-time.After(nextSlotDelay):
 		}
-	case SyncParticipationCount:
-		return cl.GetSyncParticipationCountAtSlot(slotNumber)
-
-	case SyncParticipationPercentage:
-		return cl.GetSyncParticipationPercentageAtSlot(slotNumber)
 	}
+}
 
-	return nil, fmt.Errorf("invalid data name: %s", dataName)
+func (cl *BeaconClient) GetDataPoint(dataName MetricName, slotNumber uint64) (interface{}, error) {
+	// We fetch information only for previous slots or slots which block has been announced,
+	// not current ongoing slot
+	cl.waitForSlot(slotNumber)
+	return FetchMetric(cl, dataName, slotNumber)
 }
 
 func (cl *BeaconClient) Ctx() context.Context {
@@ -390,6 +473,25 @@ func (cl *BeaconClient) Ctx() context.Context {
 	return cl.lastCtx
 }
 
+// BeaconAPIError is returned when the beacon node responds with an error status code
+type BeaconAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *BeaconAPIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unknown error, status code: %d", e.StatusCode)
+	}
+	return e.Message
+}
+
+// Whether the error is the beacon node reporting the requested resource does not exist
+func IsNotFound(err error) bool {
+	var apiErr *BeaconAPIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 type errorResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -401,6 +503,14 @@ type successResponse struct {
 }
 
 func (cl *BeaconClient) sendRequest(requestType string, requestEndPoint string, v interface{}) error {
+	return cl.sendUnwrappedRequest(requestType, requestEndPoint, &successResponse{
+		Data: v,
+	})
+}
+
+// Send a request which response is decoded as a whole, for endpoints which do not wrap their
+// response in `data`
+func (cl *BeaconClient) sendUnwrappedRequest(requestType string, requestEndPoint string, v interface{}) error {
 	cl.l.Lock()
 	defer cl.l.Unlock()
 	req, err := http.NewRequest(requestType, fmt.Sprintf("%s%s", cl.BaseURL, requestEndPoint), nil)
@@ -422,17 +532,16 @@ func (cl *BeaconClient) sendRequest(requestType string, requestEndPoint string,
 
 	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
 		var errRes errorResponse
+		apiErr := &BeaconAPIError{
+			StatusCode: res.StatusCode,
+		}
 		if err = json.NewDecoder(res.Body).Decode(&errRes); err == nil {
-			return errors.New(errRes.Message)
+			apiErr.Message = errRes.Message
 		}
-
-		return fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+		return apiErr
 	}
 
-	fullResponse := successResponse{
-		Data: v,
-	}
-	if err = json.NewDecoder(res.Body).Decode(&fullResponse); err != nil {
+	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
 		return err
 	}
 
@@ -451,7 +560,16 @@ func (cl *BeaconClient) ClientID() int {
 	return cl.ID
 }
 
+func (cl *BeaconClient) Node() *Node {
+	return cl.node
+}
+
 func (cl *BeaconClient) Close() error {
+	select {
+	case <-cl.closeChan:
+	default:
+		close(cl.closeChan)
+	}
 	cl.HTTPClient.CloseIdleConnections()
 	return nil
 }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+var (
+	// Delay before trying to re-establish a dropped event stream
+	EventStreamReconnectDelay = time.Second * 12
+
+	EventStreamTopics = []string{
+		HEAD_TOPIC,
+		BLOCK_TOPIC,
+		FINALIZED_CHECKPOINT_TOPIC,
+		CHAIN_REORG_TOPIC,
+	}
+)
+
+// Keeps the client subscribed to the beacon node's event stream until the client is closed.
+// While the stream is down, probes fall back to polling the client.
+func (cl *BeaconClient) eventStreamLoop() {
+	for {
+		err := cl.consumeEventStream()
+		cl.setSubscribed(false)
+		select {
+		case <-cl.closeChan:
+			return
+		default:
+		}
+		log15.Warn("Beacon event stream dropped, falling back to polling", "client", cl.ClientType(), "clientID", cl.ClientID(), "error", err)
+		select {
+		case <-cl.closeChan:
+			return
+		case <-time.After(EventStreamReconnectDelay):
+		}
+	}
+}
+
+func (cl *BeaconClient) consumeEventStream() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-cl.closeChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, GET_REQUEST, fmt.Sprintf("%s%s", cl.BaseURL, fmt.Sprintf(V1_EVENTS_ENDPOINT, strings.Join(EventStreamTopics, ","))), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := cl.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected event stream status code: %d", res.StatusCode)
+	}
+
+	log15.Info("Subscribed to beacon event stream", "client", cl.ClientType(), "clientID", cl.ClientID())
+	cl.setSubscribed(true)
+
+	var (
+		reader = bufio.NewReader(res.Body)
+		event  string
+		data   strings.Builder
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("event stream closed by the node")
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			// An empty line dispatches the event
+			if event != "" && data.Len() > 0 {
+				if err := cl.handleEvent(event, []byte(data.String())); err != nil {
+					log15.Debug("Unable to handle beacon event", "client", cl.ClientType(), "clientID", cl.ClientID(), "event", event, "error", err)
+				}
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by some nodes as keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteString("\n")
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+}
+
+func (cl *BeaconClient) handleEvent(event string, data []byte) error {
+	switch event {
+	case HEAD_TOPIC:
+		var head HeadEvent
+		if err := json.Unmarshal(data, &head); err != nil {
+			return err
+		}
+		cl.updateHeadSlot(head.Slot)
+	case BLOCK_TOPIC:
+		var block BlockEvent
+		if err := json.Unmarshal(data, &block); err != nil {
+			return err
+		}
+		cl.updateHeadSlot(block.Slot)
+	case FINALIZED_CHECKPOINT_TOPIC:
+		var finalized FinalizedCheckpointEvent
+		if err := json.Unmarshal(data, &finalized); err != nil {
+			return err
+		}
+		log15.Info("New finalized checkpoint", "client", cl.ClientType(), "clientID", cl.ClientID(), "epoch", finalized.Epoch, "root", finalized.Block)
+		cl.eventsL.Lock()
+		cl.FinalizedCheckpoint = &FinalityCheckpoint{
+			Epoch: finalized.Epoch,
+			Root:  finalized.Block,
+		}
+		cl.eventsL.Unlock()
+	case CHAIN_REORG_TOPIC:
+		var reorg ChainReorgEvent
+		if err := json.Unmarshal(data, &reorg); err != nil {
+			return err
+		}
+		log15.Warn("Chain reorg", "client", cl.ClientType(), "clientID", cl.ClientID(), "slot", reorg.Slot, "depth", reorg.Depth, "old_head", reorg.OldHeadBlock, "new_head", reorg.NewHeadBlock)
+		fromSlot := uint64(0)
+		if reorg.Slot > reorg.Depth {
+			fromSlot = reorg.Slot - reorg.Depth
+		}
+		cl.InvalidateSlots(fromSlot, reorg.Slot)
+	default:
+		return fmt.Errorf("unknown event: %s", event)
+	}
+	cl.newData.Notify()
+	return nil
+}
+
+func (cl *BeaconClient) setSubscribed(subscribed bool) {
+	cl.eventsL.Lock()
+	defer cl.eventsL.Unlock()
+	cl.subscribed = subscribed
+}
+
+func (cl *BeaconClient) updateHeadSlot(slot uint64) {
+	cl.eventsL.Lock()
+	defer cl.eventsL.Unlock()
+	if cl.HeadSlot == nil || slot > *cl.HeadSlot {
+		cl.HeadSlot = &slot
+	}
+}
+
+// Get the latest slot announced by the event stream, nil if no block has been announced yet
+func (cl *BeaconClient) GetHeadSlot() *uint64 {
+	cl.eventsL.Lock()
+	defer cl.eventsL.Unlock()
+	return cl.HeadSlot
+}
+
+func (cl *BeaconClient) SubscribeNewData() <-chan interface{} {
+	return cl.newData.Subscribe()
+}
+
+func (cl *BeaconClient) IsSubscribed() bool {
+	cl.eventsL.Lock()
+	defer cl.eventsL.Unlock()
+	return cl.subscribed
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestConsumeEventStream(t *testing.T) {
+	hash := func(n int64) string {
+		return common.BigToHash(big.NewInt(n)).Hex()
+	}
+	stream := ": keep-alive\n\n" +
+		"event: head\n" +
+		"data: {\"slot\":\"10\",\"block\":\"" + hash(1) + "\",\"state\":\"" + hash(2) + "\",\"epoch_transition\":false}\n\n" +
+		// Data split across several lines, with CRLF line endings
+		"event: block\r\n" +
+		"data: {\"slot\":\"12\",\r\n" +
+		"data: \"block\":\"" + hash(3) + "\"}\r\n\r\n" +
+		// Older slot, must not move the head back
+		"event: head\n" +
+		"data: {\"slot\":\"11\",\"block\":\"" + hash(4) + "\",\"state\":\"" + hash(5) + "\",\"epoch_transition\":false}\n\n" +
+		// Unknown event and event without data, both ignored
+		"event: unknown\n" +
+		"data: {}\n\n" +
+		"event: head\n\n" +
+		"event: finalized_checkpoint\n" +
+		"data: {\"block\":\"" + hash(6) + "\",\"state\":\"" + hash(7) + "\",\"epoch\":\"2\"}\n\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, stream)
+	}))
+	defer server.Close()
+
+	cl := &BeaconClient{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		cache:      NewDataCache(DefaultDataCacheSize),
+		closeChan:  make(chan interface{}),
+	}
+	newData := cl.SubscribeNewData()
+
+	if err := cl.consumeEventStream(); err == nil {
+		t.Fatal("expected an error once the stream is closed")
+	}
+
+	if head := cl.GetHeadSlot(); head == nil || *head != 12 {
+		t.Fatalf("unexpected head slot: %v", head)
+	}
+	if cl.FinalizedCheckpoint == nil {
+		t.Fatal("finalized checkpoint not set")
+	}
+	if cl.FinalizedCheckpoint.Epoch != 2 || cl.FinalizedCheckpoint.Root != common.BigToHash(big.NewInt(6)) {
+		t.Fatalf("unexpected finalized checkpoint: %+v", cl.FinalizedCheckpoint)
+	}
+	select {
+	case <-newData:
+	default:
+		t.Fatal("no new data notification")
+	}
+}
+
+func TestConsumeEventStreamStatusCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cl := &BeaconClient{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		closeChan:  make(chan interface{}),
+	}
+	if err := cl.consumeEventStream(); err == nil {
+		t.Fatal("expected an error for a non-200 status code")
+	}
+	if cl.IsSubscribed() {
+		t.Fatal("client subscribed to a failed stream")
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// Minimum number of slots between an attestation and its inclusion, timely head votes
+	// must be included with this delay
+	MinAttestationInclusionDelay = uint64(1)
+)
+
+// Participation of the active validators in the attestations of an epoch, as the sum of
+// the effective balances of the validators with each timely and correct vote
+type EpochParticipation struct {
+	ActiveGwei          uint64
+	SourceAttestingGwei uint64
+	TargetAttestingGwei uint64
+	HeadAttestingGwei   uint64
+}
+
+// Source of the participation of the validators in an epoch
+type ParticipationSource uint64
+
+const (
+	// Computed from the committees, attestations and validators of the standard beacon API
+	StandardParticipation ParticipationSource = iota
+	// Obtained from the client specific participation endpoints
+	NativeParticipation
+)
+
+var ParticipationSources = map[string]ParticipationSource{
+	"Standard": StandardParticipation,
+	"Native":   NativeParticipation,
+}
+
+func (ps *ParticipationSource) Set(val string) error {
+	v, ok := ParticipationSources[val]
+	if !ok {
+		return fmt.Errorf("invalid participation source: %s", val)
+	}
+	*ps = v
+	return nil
+}
+
+func (ps ParticipationSource) String() string {
+	for k, v := range ParticipationSources {
+		if ps == v {
+			return k
+		}
+	}
+	return ""
+}
+
+// Get the global validator inclusion of an epoch from a client specific endpoint
+type nativeParticipationAdapter func(cl *BeaconClient, epoch uint64) (*ValidatorInclusionGlobal, error)
+
+// The participation of epoch E is obtained from the `previous_epoch` values of epoch E+1
+func lighthouseValidatorInclusion(cl *BeaconClient, epoch uint64) (*ValidatorInclusionGlobal, error) {
+	var resp ValidatorInclusionGlobal
+	if err := cl.sendRequest(GET_REQUEST, fmt.Sprintf(LIGHTHOUSE_GLOBAL_VALIDATOR_INCLUSION, epoch+1), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func prysmValidatorParticipation(cl *BeaconClient, epoch uint64) (*ValidatorInclusionGlobal, error) {
+	var resp PrysmValidatorParticipationResponse
+	if err := cl.sendUnwrappedRequest(GET_REQUEST, fmt.Sprintf(PRYSM_VALIDATOR_PARTICIPATION, epoch+1), &resp); err != nil {
+		return nil, err
+	}
+	return &ValidatorInclusionGlobal{
+		PreviousEpochActiveGwei:          resp.Participation.PreviousEpochActiveGwei,
+		PreviousEpochAttestingGwei:       &resp.Participation.PreviousEpochAttestingGwei,
+		PreviousEpochTargetAttestingGwei: resp.Participation.PreviousEpochTargetAttestingGwei,
+		PreviousEpochHeadAttestingGwei:   resp.Participation.PreviousEpochHeadAttestingGwei,
+	}, nil
+}
+
+// Clients without a native participation endpoint use the standard participation source
+var nativeParticipationAdapters = map[ClientType]nativeParticipationAdapter{
+	Lighthouse: lighthouseValidatorInclusion,
+	Prysm:      prysmValidatorParticipation,
+}
+
+type participationFlags uint8
+
+const (
+	timelySourceFlag participationFlags = 1 << iota
+	timelyTargetFlag
+	timelyHeadFlag
+)
+
+type committeeKey struct {
+	slot  uint64
+	index uint64
+}
+
+func integerSquareRoot(n uint64) uint64 {
+	if n == math.MaxUint64 {
+		// n + 1 would overflow
+		return math.MaxUint32
+	}
+	x := n
+	y := (x + 1) / 2
+	for y < x {
+		x = y
+		y = (x + n/x) / 2
+	}
+	return x
+}
+
+// Get the root of the canonical block at the given slot, or of the latest block before it if
+// the slot was missed
+func (cl *BeaconClient) GetBlockRootAtSlot(slotNumber uint64) (common.Hash, error) {
+	for slot := slotNumber; ; slot-- {
+		header, err := cl.GetBeaconHeader(slot)
+		if err == nil {
+			return common.HexToHash(header.Root), nil
+		}
+		if !IsNotFound(err) || slot == 0 {
+			return common.Hash{}, err
+		}
+	}
+}
+
+// Get the effective balance of each of the active validators at the given slot
+func (cl *BeaconClient) GetActiveEffectiveBalances(slotNumber uint64) (map[uint64]uint64, error) {
+	endpoint := fmt.Sprintf(V1_BEACON_STATE_ACTIVE_VALIDATORS_ENDPOINT, slotNumber)
+	if cached, ok := cl.cache.Get(endpoint); ok {
+		return cached.(map[uint64]uint64), nil
+	}
+	var validators []ValidatorResponse
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &validators); err != nil {
+		return nil, err
+	}
+	balances := make(map[uint64]uint64, len(validators))
+	for _, v := range validators {
+		balances[v.Index] = v.Validator.EffectiveBalance
+	}
+	cl.cache.Add(endpoint, balances)
+	return balances, nil
+}
+
+// Get the participation of the validators in the given epoch.
+// Using the standard participation source, the participation is computed from the attestations
+// included in the blocks of the epoch and the following epoch, following the Altair participation
+// flags: source votes must be included within sqrt(SLOTS_PER_EPOCH) slots, target votes within
+// SLOTS_PER_EPOCH slots and head votes in the following slot.
+// Using the native participation source, it is obtained from the client specific endpoint when
+// the client has one.
+// The participation is only available once the following epoch has ended.
+func (cl *BeaconClient) GetEpochParticipation(epoch uint64) (*EpochParticipation, error) {
+	ongoingEpoch, err := cl.GetOngoingEpochNumber()
+	if err != nil {
+		return nil, err
+	}
+	if ongoingEpoch < epoch+2 {
+		// Attestations of the epoch can be included until the end of the following epoch
+		return nil, fmt.Errorf("No information available yet")
+	}
+	cacheKey := fmt.Sprintf("participation/%d", epoch)
+	if cached, ok := cl.cache.Get(cacheKey); ok {
+		return cached.(*EpochParticipation), nil
+	}
+
+	var participation *EpochParticipation
+	if adapter, ok := nativeParticipationAdapters[cl.ClientType()]; ok && cl.ParticipationSource == NativeParticipation {
+		participation, err = cl.nativeEpochParticipation(adapter, epoch)
+	} else {
+		participation, err = cl.standardEpochParticipation(epoch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cl.cache.Add(cacheKey, participation)
+	return participation, nil
+}
+
+// Get the participation of the validators in the given epoch from a client specific endpoint.
+// Endpoints which do not report the source votes, such as the Lighthouse one since Altair, get
+// them from the standard participation.
+func (cl *BeaconClient) nativeEpochParticipation(adapter nativeParticipationAdapter, epoch uint64) (*EpochParticipation, error) {
+	inclusion, err := adapter(cl, epoch)
+	if err != nil {
+		return nil, err
+	}
+	if inclusion.PreviousEpochActiveGwei == 0 {
+		return nil, fmt.Errorf("no active balance for epoch %d", epoch)
+	}
+	participation := &EpochParticipation{
+		ActiveGwei:          inclusion.PreviousEpochActiveGwei,
+		TargetAttestingGwei: inclusion.PreviousEpochTargetAttestingGwei,
+		HeadAttestingGwei:   inclusion.PreviousEpochHeadAttestingGwei,
+	}
+	if inclusion.PreviousEpochAttestingGwei != nil {
+		participation.SourceAttestingGwei = *inclusion.PreviousEpochAttestingGwei
+	} else {
+		standard, err := cl.standardEpochParticipation(epoch)
+		if err != nil {
+			return nil, err
+		}
+		participation.SourceAttestingGwei = standard.SourceAttestingGwei
+	}
+	return participation, nil
+}
+
+// Get the participation of the validators in the given epoch from the committees, attestations
+// and validators of the standard beacon API
+func (cl *BeaconClient) standardEpochParticipation(epoch uint64) (*EpochParticipation, error) {
+	startSlot := epoch * cl.Spec.SlotsPerEpoch
+	allCommittees, err := cl.GetEpochCommittees(epoch)
+	if err != nil {
+		return nil, err
+	}
+	committees := make(map[committeeKey]Validators)
+	for _, c := range allCommittees {
+		committees[committeeKey{slot: c.Slot, index: c.Index}] = c.Validators
+	}
+	balances, err := cl.GetActiveEffectiveBalances(startSlot)
+	if err != nil {
+		return nil, err
+	}
+	targetRoot, err := cl.GetBlockRootAtSlot(startSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		flags              = make(map[uint64]participationFlags)
+		timelySourceWindow = integerSquareRoot(cl.Spec.SlotsPerEpoch)
+		timelyTargetWindow = cl.Spec.SlotsPerEpoch
+	)
+	for inclusionSlot := startSlot + 1; inclusionSlot < startSlot+2*cl.Spec.SlotsPerEpoch; inclusionSlot++ {
+		block, err := cl.GetBeaconBlock(inclusionSlot)
+		if err != nil {
+			if IsNotFound(err) {
+				// Missed slot
+				continue
+			}
+			return nil, err
+		}
+		for _, att := range block.BlockMessage.Body.Attestations {
+			if cl.EpochForSlot(att.Data.Slot) != epoch {
+				continue
+			}
+			committee, ok := committees[committeeKey{slot: att.Data.Slot, index: att.Data.Index}]
+			if !ok {
+				continue
+			}
+			headRoot, err := cl.GetBlockRootAtSlot(att.Data.Slot)
+			if err != nil {
+				return nil, err
+			}
+			var (
+				// The source of included attestations always matches the justified checkpoint
+				inclusionDelay = inclusionSlot - att.Data.Slot
+				matchingTarget = att.Data.Target.Root == targetRoot
+				matchingHead   = matchingTarget && common.HexToHash(att.Data.BeaconBlockRoot) == headRoot
+				attFlags       participationFlags
+			)
+			if inclusionDelay <= timelySourceWindow {
+				attFlags |= timelySourceFlag
+			}
+			if matchingTarget && inclusionDelay <= timelyTargetWindow {
+				attFlags |= timelyTargetFlag
+			}
+			if matchingHead && inclusionDelay == MinAttestationInclusionDelay {
+				attFlags |= timelyHeadFlag
+			}
+			// The bit following the committee members is the length bit of the bitlist
+			for i, validator := range committee {
+				if att.AggregationBits.BitAt(uint64(i)) {
+					flags[validator] |= attFlags
+				}
+			}
+		}
+	}
+
+	participation := &EpochParticipation{}
+	for _, committee := range committees {
+		for _, validator := range committee {
+			balance := balances[validator]
+			participation.ActiveGwei += balance
+			if flags[validator]&timelySourceFlag != 0 {
+				participation.SourceAttestingGwei += balance
+			}
+			if flags[validator]&timelyTargetFlag != 0 {
+				participation.TargetAttestingGwei += balance
+			}
+			if flags[validator]&timelyHeadFlag != 0 {
+				participation.HeadAttestingGwei += balance
+			}
+		}
+	}
+	if participation.ActiveGwei == 0 {
+		return nil, fmt.Errorf("no active balance for epoch %d", epoch)
+	}
+	return participation, nil
+}
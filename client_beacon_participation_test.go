@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestIntegerSquareRoot(t *testing.T) {
+	tests := map[uint64]uint64{
+		0:                    0,
+		1:                    1,
+		3:                    1,
+		4:                    2,
+		32:                   5,
+		1 << 32:              1 << 16,
+		18446744073709551615: 4294967295,
+	}
+	for n, expected := range tests {
+		if root := integerSquareRoot(n); root != expected {
+			t.Errorf("integerSquareRoot(%d): expected %d, got %d", n, expected, root)
+		}
+	}
+}
+
+// Responses of a fake beacon node for the standard participation of epoch 1, in which the
+// validators 1 to 4 have an effective balance of 10, 20, 40 and 80 and the following votes:
+//
+//	Validator 1: timely source, target and head
+//	Validator 2: timely source
+//	Validator 3: timely source and target
+//	Validator 4: timely target
+func standardParticipationResponses() map[string]string {
+	var (
+		rootAt = func(slot uint64) string {
+			return common.BigToHash(new(big.Int).SetUint64(100 + slot)).Hex()
+		}
+		wrongRoot = common.BigToHash(big.NewInt(1)).Hex()
+		header    = func(slot uint64) string {
+			return fmt.Sprintf(`{"root":"%s","canonical":true,"header":{"message":{"slot":"%d"}}}`, rootAt(slot), slot)
+		}
+		vote = func(slot uint64, index uint64, bits string, head string, target string) string {
+			return fmt.Sprintf(`{"aggregation_bits":"%s","data":{"slot":"%d","index":"%d","beacon_block_root":"%s","target":{"epoch":"1","root":"%s"}},"signature":"0x"}`, bits, slot, index, head, target)
+		}
+	)
+	// Epoch 1 spans slots 4 to 7, its attestations can be included until slot 11
+	return map[string]string{
+		fmt.Sprintf(V1_BEACON_STATE_COMMITTEES_ENDPOINT, 4): `[
+			{"slot":"4","index":"0","validators":["1","2"]},
+			{"slot":"5","index":"0","validators":["3","4"]}
+		]`,
+		fmt.Sprintf(V1_BEACON_STATE_ACTIVE_VALIDATORS_ENDPOINT, 4): `[
+			{"index":"1","status":"active_ongoing","validator":{"effective_balance":"10"}},
+			{"index":"2","status":"active_ongoing","validator":{"effective_balance":"20"}},
+			{"index":"3","status":"active_ongoing","validator":{"effective_balance":"40"}},
+			{"index":"4","status":"active_ongoing","validator":{"effective_balance":"80"}}
+		]`,
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 4): header(4),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 5): header(5),
+		// Validator 1: timely source, target and head
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 5): attestationsBlock(5,
+			vote(4, 0, "0x05", rootAt(4), rootAt(4)),
+		),
+		// Validator 2: timely source, wrong target
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 6): attestationsBlock(6,
+			vote(4, 0, "0x06", rootAt(4), wrongRoot),
+		),
+		// Validator 3: timely source and target, wrong head
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 7): attestationsBlock(7,
+			vote(5, 0, "0x05", wrongRoot, rootAt(4)),
+		),
+		// Validator 4: timely target only, and an attestation of the following epoch
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 9): attestationsBlock(9,
+			vote(5, 0, "0x06", rootAt(5), rootAt(4)),
+			vote(8, 0, "0x07", rootAt(8), rootAt(8)),
+		),
+		// Slots 8, 10 and 11 are missed
+	}
+}
+
+func TestGetEpochParticipation(t *testing.T) {
+	responses := standardParticipationResponses()
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, responses)
+	participation, err := cl.GetEpochParticipation(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := EpochParticipation{
+		ActiveGwei:          150,
+		SourceAttestingGwei: 70,
+		TargetAttestingGwei: 130,
+		HeadAttestingGwei:   10,
+	}
+	if *participation != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *participation)
+	}
+
+	// Attestations of the epoch can still be included
+	cl = newFakeBeaconClient(t, uint64(time.Now().Unix())-11, responses)
+	if _, err := cl.GetEpochParticipation(1); err == nil {
+		t.Fatal("expected an error before the end of the following epoch")
+	}
+}
+
+// Responses in the format of the Lighthouse (since Altair) and Prysm participation endpoints,
+// for epoch 2 which previous epoch values are the participation of epoch 1
+const (
+	lighthouseGlobalValidatorInclusion = `{
+		"current_epoch_active_gwei": 150,
+		"previous_epoch_active_gwei": 150,
+		"current_epoch_target_attesting_gwei": 80,
+		"previous_epoch_target_attesting_gwei": 131,
+		"previous_epoch_head_attesting_gwei": 11
+	}`
+	prysmValidatorParticipationResponse = `{
+		"epoch": "2",
+		"finalized": false,
+		"participation": {
+			"global_participation_rate": 0.8733333,
+			"voted_ether": "131",
+			"eligible_ether": "150",
+			"current_epoch_active_gwei": "150",
+			"current_epoch_attesting_gwei": "80",
+			"current_epoch_target_attesting_gwei": "80",
+			"previous_epoch_active_gwei": "150",
+			"previous_epoch_attesting_gwei": "71",
+			"previous_epoch_target_attesting_gwei": "131",
+			"previous_epoch_head_attesting_gwei": "11"
+		}
+	}`
+)
+
+func TestNativeParticipationAdapters(t *testing.T) {
+	source := uint64(71)
+	tests := []struct {
+		clientType ClientType
+		endpoint   string
+		response   string
+		expected   ValidatorInclusionGlobal
+	}{
+		{
+			clientType: Lighthouse,
+			endpoint:   fmt.Sprintf(LIGHTHOUSE_GLOBAL_VALIDATOR_INCLUSION, 2),
+			response:   lighthouseGlobalValidatorInclusion,
+			expected: ValidatorInclusionGlobal{
+				PreviousEpochActiveGwei:          150,
+				PreviousEpochTargetAttestingGwei: 131,
+				PreviousEpochHeadAttestingGwei:   11,
+			},
+		},
+		{
+			clientType: Prysm,
+			endpoint:   fmt.Sprintf(PRYSM_VALIDATOR_PARTICIPATION, 2),
+			response:   fakeUnwrapped + prysmValidatorParticipationResponse,
+			expected: ValidatorInclusionGlobal{
+				PreviousEpochActiveGwei:          150,
+				PreviousEpochAttestingGwei:       &source,
+				PreviousEpochTargetAttestingGwei: 131,
+				PreviousEpochHeadAttestingGwei:   11,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.clientType.String(), func(t *testing.T) {
+			cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, map[string]string{
+				test.endpoint: test.response,
+			})
+			inclusion, err := nativeParticipationAdapters[test.clientType](cl, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*inclusion, test.expected) {
+				t.Fatalf("expected %+v, got %+v", test.expected, *inclusion)
+			}
+		})
+	}
+}
+
+func TestGetNativeEpochParticipation(t *testing.T) {
+	tests := []struct {
+		clientType ClientType
+		endpoint   string
+		response   string
+		expected   EpochParticipation
+	}{
+		{
+			// The source votes are obtained from the standard participation
+			clientType: Lighthouse,
+			endpoint:   fmt.Sprintf(LIGHTHOUSE_GLOBAL_VALIDATOR_INCLUSION, 2),
+			response:   lighthouseGlobalValidatorInclusion,
+			expected: EpochParticipation{
+				ActiveGwei:          150,
+				SourceAttestingGwei: 70,
+				TargetAttestingGwei: 131,
+				HeadAttestingGwei:   11,
+			},
+		},
+		{
+			clientType: Prysm,
+			endpoint:   fmt.Sprintf(PRYSM_VALIDATOR_PARTICIPATION, 2),
+			response:   fakeUnwrapped + prysmValidatorParticipationResponse,
+			expected: EpochParticipation{
+				ActiveGwei:          150,
+				SourceAttestingGwei: 71,
+				TargetAttestingGwei: 131,
+				HeadAttestingGwei:   11,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.clientType.String(), func(t *testing.T) {
+			responses := standardParticipationResponses()
+			responses[test.endpoint] = test.response
+			cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, responses)
+			cl.Type = test.clientType
+			cl.ParticipationSource = NativeParticipation
+			participation, err := cl.GetEpochParticipation(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *participation != test.expected {
+				t.Fatalf("expected %+v, got %+v", test.expected, *participation)
+			}
+		})
+	}
+}
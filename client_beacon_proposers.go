@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// Get the index of the validator scheduled to propose at each slot of the given epoch
+func (cl *BeaconClient) GetProposerDuties(epoch uint64) (map[uint64]uint64, error) {
+	endpoint := fmt.Sprintf(V1_VALIDATOR_DUTIES_PROPOSER_ENDPOINT, epoch)
+	if cached, ok := cl.cache.Get(endpoint); ok {
+		return cached.(map[uint64]uint64), nil
+	}
+	var duties []ProposerDuty
+	if err := cl.sendRequest(GET_REQUEST, endpoint, &duties); err != nil {
+		return nil, err
+	}
+	proposers := make(map[uint64]uint64, len(duties))
+	for _, d := range duties {
+		proposers[d.Slot] = d.ValidatorIndex
+	}
+	cl.cache.Add(endpoint, proposers)
+	return proposers, nil
+}
+
+// Get the first epoch which proposer duties are requested.
+// Several clients only serve the duties of the current and next epochs, so the duties are only
+// requested from the epoch ongoing when they are first needed.
+func (cl *BeaconClient) getProposerDutiesStartEpoch() (uint64, error) {
+	cl.proposerDutiesLock.Lock()
+	defer cl.proposerDutiesLock.Unlock()
+	if cl.proposerDutiesStartEpoch == nil {
+		ongoingEpoch, err := cl.GetOngoingEpochNumber()
+		if err != nil {
+			return 0, err
+		}
+		log15.Info("Proposer duties tracked from the ongoing epoch", append(ClientLogCtx(cl), "epoch", ongoingEpoch)...)
+		cl.proposerDutiesStartEpoch = &ongoingEpoch
+	}
+	return *cl.proposerDutiesStartEpoch, nil
+}
+
+// Whether the proposer duties of the epoch of the given slot can be requested
+func (cl *BeaconClient) ProposerDutiesAvailable(slotNumber uint64) (bool, error) {
+	startEpoch, err := cl.getProposerDutiesStartEpoch()
+	if err != nil {
+		return false, err
+	}
+	return cl.EpochForSlot(slotNumber) >= startEpoch, nil
+}
+
+// Get the index of the validator scheduled to propose at the given slot
+func (cl *BeaconClient) GetExpectedProposer(slotNumber uint64) (uint64, error) {
+	proposers, err := cl.GetProposerDuties(cl.EpochForSlot(slotNumber))
+	if err != nil {
+		return 0, err
+	}
+	proposer, ok := proposers[slotNumber]
+	if !ok {
+		return 0, fmt.Errorf("no proposer duty for slot %d", slotNumber)
+	}
+	return proposer, nil
+}
+
+// Whether the given slot has no canonical block.
+// Only a not found response means a missed slot, any other error is returned.
+func (cl *BeaconClient) IsSlotMissed(slotNumber uint64) (bool, error) {
+	if _, err := cl.GetBeaconHeader(slotNumber); err != nil {
+		if IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// Get the number of missed slots of the given epoch
+func (cl *BeaconClient) GetEpochMissedSlots(epoch uint64) (uint64, error) {
+	startSlot := epoch * cl.Spec.SlotsPerEpoch
+	missed := uint64(0)
+	for slot := startSlot; slot < startSlot+cl.Spec.SlotsPerEpoch; slot++ {
+		isMissed, err := cl.IsSlotMissed(slot)
+		if err != nil {
+			return 0, err
+		}
+		if isMissed {
+			missed++
+		}
+	}
+	return missed, nil
+}
+
+// Get the number of consecutive proposal duties missed by the proposer expected at the given
+// slot, including the slot itself, or 0 if the block was proposed.
+// The missed duties of each validator are counted by verifying the slots in order, from the
+// first epoch which proposer duties are available up to the given slot, so each slot is only
+// verified once. The count of a slot that was already verified is only available while cached.
+func (cl *BeaconClient) GetProposerConsecutiveMissedDuties(slotNumber uint64) (uint64, error) {
+	cacheKey := fmt.Sprintf("proposer-misses/%d", slotNumber)
+	if cached, ok := cl.cache.Get(cacheKey); ok {
+		return cached.(uint64), nil
+	}
+	startEpoch, err := cl.getProposerDutiesStartEpoch()
+	if err != nil {
+		return 0, err
+	}
+
+	cl.proposerMissesLock.Lock()
+	defer cl.proposerMissesLock.Unlock()
+	if cl.proposerMisses == nil {
+		cl.proposerMisses = make(map[uint64]uint64)
+		cl.proposerMissesNextSlot = startEpoch * cl.Spec.SlotsPerEpoch
+	}
+	if slotNumber < cl.proposerMissesNextSlot {
+		return 0, fmt.Errorf("consecutive missed duties of slot %d no longer available", slotNumber)
+	}
+	var misses uint64
+	for ; cl.proposerMissesNextSlot <= slotNumber; cl.proposerMissesNextSlot++ {
+		slot := cl.proposerMissesNextSlot
+		proposer, err := cl.GetExpectedProposer(slot)
+		if err != nil {
+			return 0, err
+		}
+		isMissed, err := cl.IsSlotMissed(slot)
+		if err != nil {
+			return 0, err
+		}
+		if isMissed {
+			cl.proposerMisses[proposer]++
+		} else {
+			delete(cl.proposerMisses, proposer)
+		}
+		misses = cl.proposerMisses[proposer]
+		cl.cache.Add(fmt.Sprintf("proposer-misses/%d", slot), misses)
+	}
+	return misses, nil
+}
+
+// Get whether the proposer of the block at the given slot is not the validator scheduled to
+// propose it, returns 0 for a missed slot
+func (cl *BeaconClient) GetUnexpectedProposerAtSlot(slotNumber uint64) (uint64, error) {
+	header, err := cl.GetBeaconHeader(slotNumber)
+	if err != nil {
+		if IsNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	proposer, err := cl.GetExpectedProposer(slotNumber)
+	if err != nil {
+		return 0, err
+	}
+	if header.Header.Message.ProposerIndex != proposer {
+		log15.Warn("Unexpected block proposer", append(ClientLogCtx(cl), "slot", slotNumber, "expected", proposer, "actual", header.Header.Message.ProposerIndex)...)
+		return 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func beaconHeader(slot uint64, proposer uint64) string {
+	return fmt.Sprintf(`{"root":"0x%064x","canonical":true,"header":{"message":{"slot":"%d","proposer_index":"%d"}}}`, slot, slot, proposer)
+}
+
+// Proposer duties of the slots starting at the given slot
+func proposerDuties(startSlot uint64, validators ...uint64) string {
+	duties := make([]string, len(validators))
+	for i, v := range validators {
+		duties[i] = fmt.Sprintf(`{"pubkey":"0x","validator_index":"%d","slot":"%d"}`, v, startSlot+uint64(i))
+	}
+	return "[" + strings.Join(duties, ",") + "]"
+}
+
+func TestIsSlotMissed(t *testing.T) {
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, map[string]string{
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 1): beaconHeader(1, 0),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 3): fakeInternalError,
+	})
+	if missed, err := cl.IsSlotMissed(1); err != nil || missed {
+		t.Fatalf("expected slot 1 not missed: %t, %v", missed, err)
+	}
+	if missed, err := cl.IsSlotMissed(2); err != nil || !missed {
+		t.Fatalf("expected slot 2 missed: %t, %v", missed, err)
+	}
+	if _, err := cl.IsSlotMissed(3); err == nil {
+		t.Fatal("expected an error for slot 3")
+	}
+}
+
+func TestGetEpochMissedSlots(t *testing.T) {
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, map[string]string{
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 4): beaconHeader(4, 0),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 6): beaconHeader(6, 0),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 9): fakeInternalError,
+	})
+	if missed, err := cl.GetEpochMissedSlots(1); err != nil || missed != 2 {
+		t.Fatalf("expected 2 missed slots in epoch 1: %d, %v", missed, err)
+	}
+	if _, err := cl.GetEpochMissedSlots(2); err == nil {
+		t.Fatal("expected an error for epoch 2")
+	}
+}
+
+func TestGetProposerConsecutiveMissedDuties(t *testing.T) {
+	// Duties are tracked from epoch 1, the ongoing epoch
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-5, map[string]string{
+		fmt.Sprintf(V1_VALIDATOR_DUTIES_PROPOSER_ENDPOINT, 1): proposerDuties(4, 1, 2, 1, 1),
+		fmt.Sprintf(V1_VALIDATOR_DUTIES_PROPOSER_ENDPOINT, 2): proposerDuties(8, 1, 2, 1, 2),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 5):            beaconHeader(5, 2),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 7):            beaconHeader(7, 1),
+		fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 11):           beaconHeader(11, 2),
+	})
+	expected := map[uint64]uint64{
+		4:  1, // Validator 1 missed
+		5:  0, // Validator 2 proposed
+		6:  2, // Validator 1 missed again
+		7:  0, // Validator 1 proposed
+		8:  1, // Validator 1 missed, after its proposal
+		9:  1, // Validator 2 missed
+		10: 2, // Validator 1 missed again
+		11: 0, // Validator 2 proposed
+	}
+	// Slots requested out of order get the same counts
+	for _, slot := range []uint64{10, 4, 6, 5, 11, 9, 8, 7} {
+		misses, err := cl.GetProposerConsecutiveMissedDuties(slot)
+		if err != nil {
+			t.Fatalf("slot %d: %v", slot, err)
+		}
+		if misses != expected[slot] {
+			t.Errorf("slot %d: expected %d consecutive missed duties, got %d", slot, expected[slot], misses)
+		}
+	}
+	if _, err := cl.GetProposerConsecutiveMissedDuties(3); err == nil {
+		t.Fatal("expected an error for a slot before the tracked duties")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	// Response of the fake beacon node for an endpoint which fails with an internal error
+	fakeInternalError = "<internal error>"
+	// Prefix of the responses of the fake beacon node which are not wrapped in `data`
+	fakeUnwrapped = "<unwrapped>"
+)
+
+// Create a beacon client served by a fake beacon node which responds to the given endpoints
+// with the given data, and with not found to any other endpoint
+func newFakeBeaconClient(t *testing.T, genesisTime uint64, responses map[string]string) *BeaconClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":404,"message":"not found"}`)
+			return
+		}
+		if data == fakeInternalError {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"code":500,"message":"internal error"}`)
+			return
+		}
+		if strings.HasPrefix(data, fakeUnwrapped) {
+			fmt.Fprint(w, strings.TrimPrefix(data, fakeUnwrapped))
+			return
+		}
+		fmt.Fprintf(w, `{"data":%s}`, data)
+	}))
+	t.Cleanup(server.Close)
+	return &BeaconClient{
+		BaseURL:     server.URL,
+		HTTPClient:  server.Client(),
+		Spec:        Spec{SecondsPerSlot: 1, SlotsPerEpoch: 4},
+		GenesisTime: &genesisTime,
+		cache:       NewDataCache(DefaultDataCacheSize),
+		closeChan:   make(chan interface{}),
+	}
+}
+
+func attestationsBlock(slot uint64, attestations ...string) string {
+	body := ""
+	for i, att := range attestations {
+		if i > 0 {
+			body += ","
+		}
+		body += att
+	}
+	return fmt.Sprintf(`{"message":{"slot":"%d","proposer_index":"0","body":{"attestations":[%s]}},"signature":"0x"}`, slot, body)
+}
+
+func attestation(slot uint64, index uint64, bits string) string {
+	return fmt.Sprintf(`{"aggregation_bits":"%s","data":{"slot":"%d","index":"%d"},"signature":"0x"}`, bits, slot, index)
+}
+
+func TestGetAttestationCountForSlot(t *testing.T) {
+	responses := map[string]string{
+		fmt.Sprintf(V1_BEACON_STATE_COMMITTEES_ENDPOINT, 4): `[
+			{"slot":"4","index":"0","validators":["30"]},
+			{"slot":"5","index":"0","validators":["10","11","12"]},
+			{"slot":"5","index":"1","validators":["20","21"]}
+		]`,
+		// Validators 10 and 11 of committee 0, and an attestation of a different slot
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 6): attestationsBlock(6,
+			attestation(5, 0, "0x0b"),
+			attestation(4, 0, "0x03"),
+		),
+		// Slot 7 is missed
+		// Validators 11 and 12 of committee 0, overlapping the previous aggregate, and
+		// validator 20 of committee 1
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 8): attestationsBlock(8,
+			attestation(5, 0, "0x0e"),
+			attestation(5, 1, "0x05"),
+		),
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 9): attestationsBlock(9),
+		// Validator 21 of committee 1, outside of the inclusion window
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 10): attestationsBlock(10,
+			attestation(5, 1, "0x06"),
+		),
+	}
+
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, responses)
+	count, err := cl.GetAttestationCountForSlot(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Fatalf("expected 4 attesting validators, got %d", count)
+	}
+
+	// The inclusion window of the slot has not passed yet
+	cl = newFakeBeaconClient(t, uint64(time.Now().Unix())-9, responses)
+	if _, err := cl.GetAttestationCountForSlot(5); err == nil {
+		t.Fatal("expected an error before the end of the inclusion window")
+	}
+}
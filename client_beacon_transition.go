@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// Update the terminal block found by the execution clients
+func (cl *BeaconClient) UpdateTerminalBlock(terminalBlock *types.Header) {
+	cl.terminalBlockLock.Lock()
+	defer cl.terminalBlockLock.Unlock()
+	cl.TerminalBlock = terminalBlock
+}
+
+func (cl *BeaconClient) getTerminalBlock() *types.Header {
+	cl.terminalBlockLock.Lock()
+	defer cl.terminalBlockLock.Unlock()
+	return cl.TerminalBlock
+}
+
+// Update the TTD slot information:
+// The slot at which the TTD was reached is obtained from the terminal block reported by the
+// execution clients, or, when none is expected or none was reported within an epoch of the
+// detection of the transition, from the slot preceding the first block with a non-default
+// execution payload.
+// When both are available, they are cross-checked.
+func (cl *BeaconClient) UpdateGetTTDBlockSlot() (*uint64, error) {
+	if cl.TransitionSlot == nil {
+		transitionSlot, err := cl.findTransitionSlot()
+		if err != nil {
+			log15.Debug("Unable to detect the transition slot", "client", cl.ClientType(), "clientID", cl.ClientID(), "error", err)
+		} else if transitionSlot != nil {
+			log15.Info("Transition Slot Reached", "client", cl.ClientType(), "clientID", cl.ClientID(), "slot", *transitionSlot)
+			cl.TransitionSlot = transitionSlot
+			cl.transitionDetectedAt = time.Now()
+		}
+	}
+
+	terminalBlock := cl.getTerminalBlock()
+	if cl.TransitionOutcome == nil && cl.TransitionSlot != nil && terminalBlock != nil {
+		outcome, err := cl.verifyTransition(terminalBlock)
+		if err != nil {
+			log15.Debug("Unable to cross-check the transition", "client", cl.ClientType(), "clientID", cl.ClientID(), "error", err)
+		} else {
+			cl.TransitionOutcome = outcome
+			if !outcome.Success {
+				log15.Crit("Execution and beacon transition detections disagree", "client", cl.ClientType(), "clientID", cl.ClientID(), "extra", outcome.Message)
+			}
+		}
+	}
+
+	if cl.TTDSlotNumber != nil {
+		return cl.TTDSlotNumber, nil
+	}
+	if terminalBlock != nil {
+		slotAtTTD, err := cl.SlotAtTime(terminalBlock.Time)
+		if err != nil {
+			return nil, err
+		}
+		cl.TTDSlotNumber = &slotAtTTD
+	} else if cl.TransitionSlot != nil {
+		if cl.TerminalBlockExpected {
+			// The terminal block is preferred, wait for the execution clients to report it
+			epochDuration := time.Duration(cl.Spec.SlotsPerEpoch*cl.Spec.SecondsPerSlot) * time.Second
+			if time.Since(cl.transitionDetectedAt) < epochDuration {
+				return nil, nil
+			}
+			log15.Warn("Terminal block not reported by the execution clients, using the transition slot", "client", cl.ClientType(), "clientID", cl.ClientID(), "slot", *cl.TransitionSlot)
+		}
+		slotAtTTD := uint64(0)
+		if *cl.TransitionSlot > 0 {
+			slotAtTTD = *cl.TransitionSlot - 1
+		}
+		cl.TTDSlotNumber = &slotAtTTD
+	}
+	return cl.TTDSlotNumber, nil
+}
+
+// Binary search the first slot which block contains a non-default execution payload,
+// nil if the transition has not happened yet
+func (cl *BeaconClient) findTransitionSlot() (*uint64, error) {
+	ongoingSlot, err := cl.GetOngoingSlotNumber()
+	if err != nil {
+		return nil, err
+	}
+	if cl.EpochForSlot(ongoingSlot) < cl.Spec.BellatrixForkEpoch {
+		return nil, nil
+	}
+	forkSlot := cl.Spec.BellatrixForkEpoch * cl.Spec.SlotsPerEpoch
+	if ongoingSlot <= forkSlot {
+		return nil, nil
+	}
+
+	low, high := forkSlot, ongoingSlot-1
+	if complete, err := cl.isTransitionCompleteAtSlot(high, forkSlot); err != nil || !complete {
+		return nil, err
+	}
+	for low < high {
+		mid := low + (high-low)/2
+		complete, err := cl.isTransitionCompleteAtSlot(mid, forkSlot)
+		if err != nil {
+			return nil, err
+		}
+		if complete {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return &low, nil
+}
+
+// Whether the latest block at or before the given slot, and not before the minimum slot,
+// contains a non-default execution payload
+func (cl *BeaconClient) isTransitionCompleteAtSlot(slot uint64, minSlot uint64) (bool, error) {
+	for currentSlot := slot; ; currentSlot-- {
+		block, err := cl.GetBeaconBlock(currentSlot)
+		if err == nil {
+			return !block.BlockMessage.Body.ExecutionPayload.IsDefault(), nil
+		}
+		if !IsNotFound(err) {
+			return false, err
+		}
+		if currentSlot <= minSlot {
+			return false, nil
+		}
+	}
+}
+
+// Cross-check the first non-default execution payload against the terminal block reported by
+// the execution clients
+func (cl *BeaconClient) verifyTransition(terminalBlock *types.Header) (*VerificationOutcome, error) {
+	block, err := cl.GetBeaconBlock(*cl.TransitionSlot)
+	if err != nil {
+		return nil, err
+	}
+	payload := block.BlockMessage.Body.ExecutionPayload
+	if payload.IsDefault() {
+		return nil, fmt.Errorf("default execution payload at transition slot %d", *cl.TransitionSlot)
+	}
+
+	failures := make([]string, 0)
+	if payload.ParentHash != terminalBlock.Hash() {
+		failures = append(failures, fmt.Sprintf("first payload parent hash %s does not match terminal block hash %s", payload.ParentHash, terminalBlock.Hash()))
+	}
+	if payload.BlockNumber != terminalBlock.Number.Uint64()+1 {
+		failures = append(failures, fmt.Sprintf("first payload block number %d does not follow terminal block number %d", payload.BlockNumber, terminalBlock.Number.Uint64()))
+	}
+	if terminalSlot, err := cl.SlotAtTime(terminalBlock.Time); err == nil && terminalSlot >= *cl.TransitionSlot {
+		failures = append(failures, fmt.Sprintf("terminal block slot %d is not before the transition slot %d", terminalSlot, *cl.TransitionSlot))
+	}
+
+	if len(failures) > 0 {
+		return &VerificationOutcome{
+			Success: false,
+			Message: strings.Join(failures, ", "),
+		}, nil
+	}
+	return &VerificationOutcome{
+		Success: true,
+		Message: fmt.Sprintf("first payload at slot %d builds on terminal block %d (%s)", *cl.TransitionSlot, terminalBlock.Number.Uint64(), terminalBlock.Hash()),
+	}, nil
+}
+
+func (cl *BeaconClient) ClientVerifications() []ClientVerification {
+	if cl.TransitionOutcome == nil {
+		return nil
+	}
+	return []ClientVerification{
+		{
+			VerificationName: "Transition Cross-Layer Verification",
+			Outcome:          *cl.TransitionOutcome,
+		},
+	}
+}
@@ -2,21 +2,33 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/rpc"
 	"gopkg.in/inconshreveable/log15.v2"
 )
 
 type TotalDifficulty struct {
+	Number          *hexutil.Big `json:"number"`
 	TotalDifficulty *hexutil.Big `json:"totalDifficulty"`
 }
 
+// Fields of a block returned by `eth_getBlockByNumber` that are not part of the header
+type BlockSummary struct {
+	Size         hexutil.Uint64 `json:"size"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
 type ExecutionClient struct {
 	Type   ClientType
 	ID     int
@@ -24,11 +36,35 @@ type ExecutionClient struct {
 	Eth    *ethclient.Client
 	RPC    *rpc.Client
 
+	// Node the client belongs to, if paired with a beacon client
+	node *Node
+
 	// Merge related
-	TTD                TTD
-	TTDBlockNumber     *uint64
-	TTDBlockTimestamp  uint64
-	UpdateTTDTimestamp func(uint64)
+	TTD                 TTD
+	TerminalBlockHash   common.Hash
+	TTDBlockNumber      *uint64
+	TTDBlockTimestamp   uint64
+	UpdateTerminalBlock func(*types.Header)
+	// The terminal block hash override is ignored before this time, the start of
+	// TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH
+	TerminalBlockHashActivationTime uint64
+	// Outcome of the verification of the terminal block against the spec definition,
+	// nil until the block following the terminal block is available
+	TerminalBlockOutcome *VerificationOutcome
+
+	// Decoded responses shared by all metrics
+	cache *DataCache
+
+	// Catch-up related
+	BatchSize    uint64
+	BatchWorkers uint64
+
+	// Subscription related
+	latestHeader *types.Header
+	subscribed   bool
+	newData      Notifier
+	headsL       sync.Mutex
+	closeChan    chan interface{}
 
 	// Lock
 	l sync.Mutex
@@ -38,19 +74,29 @@ type ExecutionClient struct {
 	lastCancel context.CancelFunc
 }
 
+// Create a new execution client, the RPC URL can be an HTTP or WebSocket URL, or an IPC path.
+// WebSocket and IPC clients are subscribed to the node's new heads.
 func NewExecutionClient(clientType ClientType, id int, rpcUrl string) (*ExecutionClient, error) {
-	rpcClient, err := rpc.DialHTTP(rpcUrl)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	rpcClient, err := rpc.DialContext(ctx, rpcUrl)
 	if err != nil {
 		return nil, err
 	}
 
-	return &ExecutionClient{
-		Type:   clientType,
-		ID:     id,
-		RPCUrl: rpcUrl,
-		Eth:    ethclient.NewClient(rpcClient),
-		RPC:    rpcClient,
-	}, nil
+	el := &ExecutionClient{
+		Type:      clientType,
+		ID:        id,
+		RPCUrl:    rpcUrl,
+		Eth:       ethclient.NewClient(rpcClient),
+		RPC:       rpcClient,
+		cache:     NewDataCache(DefaultDataCacheSize),
+		closeChan: make(chan interface{}),
+	}
+
+	go el.newHeadsLoop()
+
+	return el, nil
 }
 
 func (el *ExecutionClient) ClientLayer() ClientLayer {
@@ -65,85 +111,314 @@ func (el *ExecutionClient) ClientVersion() (string, error) {
 	return *clientVersion, nil
 }
 
+// Get the total difficulty of the given block, or the latest block if nil
+func (el *ExecutionClient) getTotalDifficulty(blockNumber *uint64) (*TotalDifficulty, error) {
+	blockNumberArg := "latest"
+	if blockNumber != nil {
+		blockNumberArg = hexutil.EncodeUint64(*blockNumber)
+	}
+	var td *TotalDifficulty
+	if err := el.RPC.CallContext(el.Ctx(), &td, "eth_getBlockByNumber", blockNumberArg, false); err != nil {
+		return nil, err
+	}
+	if td == nil || td.Number == nil || td.TotalDifficulty == nil {
+		return nil, fmt.Errorf("unable to get total difficulty of block %s", blockNumberArg)
+	}
+	return td, nil
+}
+
 func (el *ExecutionClient) UpdateGetTTDBlockSlot() (*uint64, error) {
 	el.l.Lock()
 	defer el.l.Unlock()
 
 	if el.TTDBlockNumber == nil {
-		var td *TotalDifficulty
-		if err := el.RPC.CallContext(el.Ctx(), &td, "eth_getBlockByNumber", "latest", false); err != nil {
+		var (
+			terminalHeader *types.Header
+			err            error
+		)
+		if el.TerminalBlockHash != (common.Hash{}) {
+			terminalHeader, err = el.findTerminalBlockByHash()
+		} else {
+			terminalHeader, err = el.findTerminalBlockByTTD()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if terminalHeader != nil {
+			bn := terminalHeader.Number.Uint64()
+			el.TTDBlockNumber = &bn
+			el.TTDBlockTimestamp = terminalHeader.Time
+			if el.UpdateTerminalBlock != nil {
+				el.UpdateTerminalBlock(terminalHeader)
+			}
+			log15.Info("TTD Block Reached", "client", el.ClientID(), "block", bn)
+		}
+	}
+	if el.TTDBlockNumber != nil && el.TerminalBlockOutcome == nil {
+		outcome, err := el.verifyTerminalBlock(*el.TTDBlockNumber)
+		if err != nil {
+			log15.Debug("Unable to verify terminal block yet", "client", el.ClientType(), "clientID", el.ClientID(), "error", err)
+		} else if outcome != nil {
+			el.TerminalBlockOutcome = outcome
+			if !outcome.Success {
+				log15.Crit("Terminal block does not satisfy the spec definition", "client", el.ClientType(), "clientID", el.ClientID(), "block", *el.TTDBlockNumber, "extra", outcome.Message)
+			}
+		}
+	}
+	return el.TTDBlockNumber, nil
+}
+
+// Find the terminal block by binary searching the first block which total difficulty reaches
+// the TTD, nil if the TTD has not been reached yet
+func (el *ExecutionClient) findTerminalBlockByTTD() (*types.Header, error) {
+	latestTD, err := el.getTotalDifficulty(nil)
+	if err != nil {
+		return nil, err
+	}
+	if latestTD.TotalDifficulty.ToInt().Cmp(el.TTD.Int) < 0 {
+		return nil, nil
+	}
+	low, high := uint64(0), latestTD.Number.ToInt().Uint64()
+	for low < high {
+		mid := low + (high-low)/2
+		midTD, err := el.getTotalDifficulty(&mid)
+		if err != nil {
 			return nil, err
 		}
+		if midTD.TotalDifficulty.ToInt().Cmp(el.TTD.Int) >= 0 {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return el.Eth.HeaderByNumber(el.Ctx(), new(big.Int).SetUint64(low))
+}
+
+// Find the terminal block by its hash, nil if the block has not been produced yet or the
+// terminal block hash override is not active yet
+func (el *ExecutionClient) findTerminalBlockByHash() (*types.Header, error) {
+	if uint64(time.Now().Unix()) < el.TerminalBlockHashActivationTime {
+		return nil, nil
+	}
+	terminalHeader, err := el.Eth.HeaderByHash(el.Ctx(), el.TerminalBlockHash)
+	if err != nil {
+		if err == ethereum.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return terminalHeader, nil
+}
+
+// Verify that the terminal block satisfies the spec definition: TD >= TTD and parent TD < TTD,
+// or its hash is the TERMINAL_BLOCK_HASH, and that it is the last proof-of-work block of the chain.
+// Returns nil if the block following the terminal block is not available yet.
+func (el *ExecutionClient) verifyTerminalBlock(terminalBlockNumber uint64) (*VerificationOutcome, error) {
+	nextBlockNumber := terminalBlockNumber + 1
+	nextHeader, err := el.Eth.HeaderByNumber(el.Ctx(), new(big.Int).SetUint64(nextBlockNumber))
+	if err != nil {
+		if err == ethereum.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	terminalHeader, err := el.Eth.HeaderByNumber(el.Ctx(), new(big.Int).SetUint64(terminalBlockNumber))
+	if err != nil {
+		return nil, err
+	}
 
-		if td.TotalDifficulty.ToInt().Cmp(el.TTD.Int) >= 0 {
-			// TTD has been reached, we need to go backwards from latest block to find the non-zero difficulty block
-			latestHeader, err := el.Eth.BlockByNumber(el.Ctx(), nil)
+	failures := make([]string, 0)
+	if el.TerminalBlockHash != (common.Hash{}) {
+		if terminalHeader.Hash() != el.TerminalBlockHash {
+			failures = append(failures, fmt.Sprintf("canonical block %d hash %s does not match terminal block hash %s", terminalBlockNumber, terminalHeader.Hash(), el.TerminalBlockHash))
+		}
+		if nextHeader.Time < el.TerminalBlockHashActivationTime {
+			failures = append(failures, fmt.Sprintf("block %d following the terminal block precedes TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH", nextBlockNumber))
+		}
+	} else {
+		terminalTD, err := el.getTotalDifficulty(&terminalBlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if terminalTD.TotalDifficulty.ToInt().Cmp(el.TTD.Int) < 0 {
+			failures = append(failures, fmt.Sprintf("terminal block TD %v < TTD %v", terminalTD.TotalDifficulty.ToInt(), el.TTD.Int))
+		}
+		if terminalBlockNumber > 0 {
+			parentBlockNumber := terminalBlockNumber - 1
+			parentTD, err := el.getTotalDifficulty(&parentBlockNumber)
 			if err != nil {
 				return nil, err
 			}
-			for currentNumber := int64(latestHeader.NumberU64()); currentNumber >= 0; currentNumber-- {
-				currentHeader, err := el.Eth.BlockByNumber(el.Ctx(), big.NewInt(currentNumber))
-				if err != nil {
-					return nil, err
-				}
-				if currentHeader.Difficulty().Cmp(big.NewInt(0)) > 0 {
-					// We got the first block from head with a non-zero difficulty, this is the TTD block
-					bn := currentHeader.Number().Uint64()
-					el.TTDBlockNumber = &bn
-					el.TTDBlockTimestamp = currentHeader.Time()
-					if el.UpdateTTDTimestamp != nil {
-						el.UpdateTTDTimestamp(el.TTDBlockTimestamp)
-					}
-					log15.Info("TTD Block Reached", "client", el.ClientID(), "block", bn)
-					break
-				}
-				if currentNumber == 0 {
-					return nil, fmt.Errorf("unable to get TTD Block")
-				}
+			if parentTD.TotalDifficulty.ToInt().Cmp(el.TTD.Int) >= 0 {
+				failures = append(failures, fmt.Sprintf("terminal block parent TD %v >= TTD %v", parentTD.TotalDifficulty.ToInt(), el.TTD.Int))
 			}
 		}
 	}
-	return el.TTDBlockNumber, nil
+	if terminalHeader.Difficulty.Sign() == 0 && terminalBlockNumber > 0 {
+		failures = append(failures, "terminal block has zero difficulty")
+	}
+	if nextHeader.Difficulty.Sign() != 0 {
+		failures = append(failures, fmt.Sprintf("block %d following the terminal block has non-zero difficulty %v", nextBlockNumber, nextHeader.Difficulty))
+	}
+	if nextHeader.ParentHash != terminalHeader.Hash() {
+		failures = append(failures, fmt.Sprintf("block %d parent hash %s does not match terminal block hash %s", nextBlockNumber, nextHeader.ParentHash, terminalHeader.Hash()))
+	}
+
+	if len(failures) > 0 {
+		return &VerificationOutcome{
+			Success: false,
+			Message: strings.Join(failures, ", "),
+		}, nil
+	}
+	return &VerificationOutcome{
+		Success: true,
+		Message: fmt.Sprintf("terminal block %d (%s) satisfies the spec definition", terminalBlockNumber, terminalHeader.Hash()),
+	}, nil
+}
+
+func (el *ExecutionClient) ClientVerifications() []ClientVerification {
+	if el.TerminalBlockOutcome == nil {
+		return nil
+	}
+	return []ClientVerification{
+		{
+			VerificationName: "Terminal Block Verification",
+			Outcome:          *el.TerminalBlockOutcome,
+		},
+	}
 }
 
 func (el *ExecutionClient) GetLatestBlockSlotNumber() (uint64, error) {
+	if latestNumber := el.getSubscriptionLatestNumber(); latestNumber != nil {
+		return *latestNumber, nil
+	}
 	el.l.Lock()
-	defer el.l.Unlock()
-	return el.Eth.BlockNumber(el.Ctx())
+	header, err := el.Eth.HeaderByNumber(el.Ctx(), nil)
+	el.l.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	// Without the subscription, a reorg is only noticed through the latest header
+	el.cacheHeader(header)
+	el.invalidateReorgedAncestors(header)
+	return header.Number.Uint64(), nil
 }
 
-func (el *ExecutionClient) GetDataPoint(dataName MetricName, blockNumber uint64) (interface{}, error) {
+func headerCacheKey(blockNumber uint64) string {
+	return fmt.Sprintf("header/%d", blockNumber)
+}
+
+func blockCacheKey(blockNumber uint64) string {
+	return fmt.Sprintf("block/%d", blockNumber)
+}
+
+// Add a header to the cache, a header replacing a different one for the same number means a
+// reorg so the cached summary of the previous block is removed
+func (el *ExecutionClient) cacheHeader(header *types.Header) {
+	blockNumber := header.Number.Uint64()
+	if cached, ok := el.cache.Get(headerCacheKey(blockNumber)); ok && cached.(*types.Header).Hash() != header.Hash() {
+		el.cache.Remove(blockCacheKey(blockNumber))
+	}
+	el.cache.Add(headerCacheKey(blockNumber), header)
+}
+
+// Replace the cached headers of the ancestors of a canonical header which are no longer
+// canonical, and remove their cached summaries: walking back from the header, each cached
+// header which hash does not match the parent hash of its child was reorged
+func (el *ExecutionClient) invalidateReorgedAncestors(header *types.Header) {
+	for header.Number.Uint64() > 0 {
+		parentNumber := header.Number.Uint64() - 1
+		cached, ok := el.cache.Get(headerCacheKey(parentNumber))
+		if !ok || cached.(*types.Header).Hash() == header.ParentHash {
+			return
+		}
+		log15.Debug("Cached header no longer canonical", append(ClientLogCtx(el), "number", parentNumber, "hash", cached.(*types.Header).Hash())...)
+		el.cache.Remove(headerCacheKey(parentNumber))
+		el.cache.Remove(blockCacheKey(parentNumber))
+		parent, err := el.GetHeaderByHash(parentNumber, header.ParentHash)
+		if err != nil {
+			// The remaining reorged ancestors are replaced the next time
+			log15.Debug("Unable to fetch the canonical header", append(ClientLogCtx(el), "number", parentNumber, "error", err)...)
+			return
+		}
+		el.cacheHeader(parent)
+		header = parent
+	}
+}
+
+// Decode a block returned by `eth_getBlockByNumber` without the full transactions, and cache
+// its header and summary
+func (el *ExecutionClient) cacheBlock(block json.RawMessage) (*types.Header, *BlockSummary, error) {
+	if len(block) == 0 || string(block) == "null" {
+		return nil, nil, ethereum.NotFound
+	}
+	var (
+		header  types.Header
+		summary BlockSummary
+	)
+	if err := json.Unmarshal(block, &header); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(block, &summary); err != nil {
+		return nil, nil, err
+	}
+	el.cacheHeader(&header)
+	el.cache.Add(blockCacheKey(header.Number.Uint64()), &summary)
+	return &header, &summary, nil
+}
+
+func (el *ExecutionClient) GetHeader(blockNumber uint64) (*types.Header, error) {
+	if cached, ok := el.cache.Get(headerCacheKey(blockNumber)); ok {
+		return cached.(*types.Header), nil
+	}
+	return el.RefreshHeader(blockNumber)
+}
+
+// Fetch the header of a block from the node, replacing the cached one
+func (el *ExecutionClient) RefreshHeader(blockNumber uint64) (*types.Header, error) {
 	el.l.Lock()
 	defer el.l.Unlock()
 	header, err := el.Eth.HeaderByNumber(el.Ctx(), big.NewInt(int64(blockNumber)))
 	if err != nil {
 		return nil, err
 	}
-	switch dataName {
-	case ExecutionBlockCount:
-		// no error occured, we have a block
-		return uint64(1), nil
-
-	case ExecutionBaseFee:
-		return header.BaseFee, nil
-
-	case ExecutionGasUsed:
-		return header.GasUsed, nil
-
-	case ExecutionDifficulty:
-		return header.Difficulty, nil
-
-	case ExecutionMixHash:
-		return header.MixDigest.Big(), nil
+	el.cacheHeader(header)
+	return header, nil
+}
 
-	case ExecutionUnclesHash:
-		return header.UncleHash.Big(), nil
+// Get the header of a block by its hash, the cached header of the block number is used when it
+// is the same block
+func (el *ExecutionClient) GetHeaderByHash(blockNumber uint64, hash common.Hash) (*types.Header, error) {
+	if cached, ok := el.cache.Get(headerCacheKey(blockNumber)); ok && cached.(*types.Header).Hash() == hash {
+		return cached.(*types.Header), nil
+	}
+	el.l.Lock()
+	defer el.l.Unlock()
+	return el.Eth.HeaderByHash(el.Ctx(), hash)
+}
 
-	case ExecutionNonce:
-		return header.Nonce.Uint64(), nil
+// Get the size and transaction hashes of a block
+func (el *ExecutionClient) GetBlockSummary(blockNumber uint64) (*BlockSummary, error) {
+	if cached, ok := el.cache.Get(blockCacheKey(blockNumber)); ok {
+		return cached.(*BlockSummary), nil
+	}
+	var block json.RawMessage
+	if err := el.call(&block, "eth_getBlockByNumber", hexutil.EncodeUint64(blockNumber), false); err != nil {
+		return nil, err
 	}
+	_, summary, err := el.cacheBlock(block)
+	return summary, err
+}
 
-	return nil, fmt.Errorf("invalid data name: %s", dataName)
+// Call a JSON-RPC method of the node
+func (el *ExecutionClient) call(result interface{}, method string, args ...interface{}) error {
+	el.l.Lock()
+	defer el.l.Unlock()
+	return el.RPC.CallContext(el.Ctx(), result, method, args...)
+}
+
+func (el *ExecutionClient) GetDataPoint(dataName MetricName, blockNumber uint64) (interface{}, error) {
+	return FetchMetric(el, dataName, blockNumber)
 }
 
 func (el *ExecutionClient) Ctx() context.Context {
@@ -166,7 +441,16 @@ func (el *ExecutionClient) ClientID() int {
 	return el.ID
 }
 
+func (el *ExecutionClient) Node() *Node {
+	return el.node
+}
+
 func (el *ExecutionClient) Close() error {
+	select {
+	case <-el.closeChan:
+	default:
+		close(el.closeChan)
+	}
 	el.Eth.Close()
 	return nil
 }
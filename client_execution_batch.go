@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	DefaultRPCBatchSize    = uint64(100)
+	DefaultRPCBatchWorkers = uint64(4)
+
+	// Timeout for a single batch request
+	RPCBatchTimeout = 30 * time.Second
+)
+
+// Fetch the headers and summaries of a range of blocks using batched JSON-RPC requests spread across
+// a bounded pool of workers, and keep them in the cache for the probes.
+func (el *ExecutionClient) PrefetchDataPoints(fromBlock uint64, toBlock uint64) error {
+	if fromBlock > toBlock {
+		return nil
+	}
+	batchSize := el.BatchSize
+	if batchSize == 0 {
+		batchSize = DefaultRPCBatchSize
+	}
+	workers := el.BatchWorkers
+	if workers == 0 {
+		workers = DefaultRPCBatchWorkers
+	}
+
+	type blockRange struct {
+		from uint64
+		to   uint64
+	}
+	var (
+		ranges = make(chan blockRange)
+		errs   = make(chan error, workers)
+		wg     sync.WaitGroup
+	)
+	for i := uint64(0); i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range ranges {
+				if err := el.fetchHeaderBatch(r.from, r.to); err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+				}
+			}
+		}()
+	}
+	for from := fromBlock; from <= toBlock; from += batchSize {
+		to := from + batchSize - 1
+		if to > toBlock {
+			to = toBlock
+		}
+		ranges <- blockRange{
+			from: from,
+			to:   to,
+		}
+	}
+	close(ranges)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (el *ExecutionClient) fetchHeaderBatch(fromBlock uint64, toBlock uint64) error {
+	var (
+		blocks = make([]json.RawMessage, toBlock-fromBlock+1)
+		batch  = make([]rpc.BatchElem, len(blocks))
+	)
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(fromBlock + uint64(i)), false},
+			Result: &blocks[i],
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RPCBatchTimeout)
+	defer cancel()
+	if err := el.RPC.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return elem.Error
+		}
+	}
+	for _, block := range blocks {
+		if _, _, err := el.cacheBlock(block); err != nil {
+			if err == ethereum.NotFound {
+				// Block not found, the probe will retry it on its own
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+var (
+	// Delay before trying to re-subscribe to a dropped newHeads subscription
+	NewHeadsResubscribeDelay = time.Second * 12
+)
+
+// Keeps the client subscribed to the node's newHeads until the client is closed.
+// Clients connected through HTTP do not support subscriptions and are polled instead.
+func (el *ExecutionClient) newHeadsLoop() {
+	for {
+		headers := make(chan *types.Header, 16)
+		sub, err := el.Eth.SubscribeNewHead(context.Background(), headers)
+		if err != nil {
+			if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+				return
+			}
+			log15.Warn("Unable to subscribe to new heads, falling back to polling", "client", el.ClientType(), "clientID", el.ClientID(), "error", err)
+		} else {
+			log15.Info("Subscribed to new heads", "client", el.ClientType(), "clientID", el.ClientID())
+			el.setSubscribed(true)
+			err = el.consumeNewHeads(headers, sub.Err())
+			sub.Unsubscribe()
+			el.setSubscribed(false)
+			if err == nil {
+				// Client closed
+				return
+			}
+			log15.Warn("New heads subscription dropped, falling back to polling", "client", el.ClientType(), "clientID", el.ClientID(), "error", err)
+		}
+		select {
+		case <-el.closeChan:
+			return
+		case <-time.After(NewHeadsResubscribeDelay):
+		}
+	}
+}
+
+func (el *ExecutionClient) consumeNewHeads(headers <-chan *types.Header, subErr <-chan error) error {
+	for {
+		select {
+		case <-el.closeChan:
+			return nil
+		case err := <-subErr:
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return err
+		case header := <-headers:
+			el.addSubscriptionHeader(header)
+			el.invalidateReorgedAncestors(header)
+			el.newData.Notify()
+		}
+	}
+}
+
+func (el *ExecutionClient) addSubscriptionHeader(header *types.Header) {
+	el.headsL.Lock()
+	defer el.headsL.Unlock()
+	// A header for the same number as a previous one means a reorg, the new header replaces
+	// the cached one
+	el.cacheHeader(header)
+	el.latestHeader = header
+}
+
+// Get the latest block number received through the subscription, nil if none received
+// or the subscription is down
+func (el *ExecutionClient) getSubscriptionLatestNumber() *uint64 {
+	el.headsL.Lock()
+	defer el.headsL.Unlock()
+	if !el.subscribed || el.latestHeader == nil {
+		return nil
+	}
+	number := el.latestHeader.Number.Uint64()
+	return &number
+}
+
+func (el *ExecutionClient) setSubscribed(subscribed bool) {
+	el.headsL.Lock()
+	defer el.headsL.Unlock()
+	el.subscribed = subscribed
+	if !subscribed {
+		el.latestHeader = nil
+	}
+}
+
+func (el *ExecutionClient) SubscribeNewData() <-chan interface{} {
+	return el.newData.Subscribe()
+}
+
+func (el *ExecutionClient) IsSubscribed() bool {
+	el.headsL.Lock()
+	defer el.headsL.Unlock()
+	return el.subscribed
+}
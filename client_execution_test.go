@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"math/bits"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Proof-of-work chain served through the `eth` JSON-RPC namespace, every block has a
+// difficulty of 10, uses 1/3 of its gas limit, and block N has N%3 transactions and a size of
+// 500+N bytes
+type fakeEthService struct {
+	latest uint64
+	calls  uint64
+	// Blocks from this number belong to a fork replacing the previous chain, if set
+	forkFrom *uint64
+	// Timestamps of the blocks, zero for the blocks not set
+	timestamps map[uint64]uint64
+	headers    map[uint64]*types.Header
+}
+
+// Mix hash of the blocks of the fork, zero in the other blocks
+var fakeForkMixHash = common.HexToHash("0xf0")
+
+func (s *fakeEthService) header(blockNumber uint64) *types.Header {
+	if header, ok := s.headers[blockNumber]; ok {
+		return header
+	}
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(blockNumber),
+		Difficulty: big.NewInt(10),
+		GasLimit:   30000000,
+		GasUsed:    10000000,
+		Time:       s.timestamps[blockNumber],
+	}
+	if blockNumber > 0 {
+		header.ParentHash = s.header(blockNumber - 1).Hash()
+	}
+	if s.forkFrom != nil && blockNumber >= *s.forkFrom {
+		header.MixDigest = fakeForkMixHash
+	}
+	if s.headers == nil {
+		s.headers = make(map[uint64]*types.Header)
+	}
+	s.headers[blockNumber] = header
+	return header
+}
+
+// Replace the chain from the given block number by a fork
+func (s *fakeEthService) fork(fromBlock uint64) {
+	s.forkFrom = &fromBlock
+	s.headers = nil
+}
+
+func (s *fakeEthService) block(blockNumber uint64) (json.RawMessage, error) {
+	headerJSON, err := json.Marshal(s.header(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+	var block map[string]interface{}
+	if err := json.Unmarshal(headerJSON, &block); err != nil {
+		return nil, err
+	}
+	block["totalDifficulty"] = (*hexutil.Big)(new(big.Int).SetUint64(10 * (blockNumber + 1)))
+	block["size"] = hexutil.Uint64(500 + blockNumber)
+	transactions := make([]common.Hash, blockNumber%3)
+	for i := range transactions {
+		transactions[i] = common.BigToHash(new(big.Int).SetUint64(blockNumber*10 + uint64(i)))
+	}
+	block["transactions"] = transactions
+	return json.Marshal(block)
+}
+
+func (s *fakeEthService) GetBlockByNumber(number string, full bool) (json.RawMessage, error) {
+	s.calls++
+	blockNumber := s.latest
+	if number != "latest" {
+		n, err := hexutil.DecodeUint64(number)
+		if err != nil {
+			return nil, err
+		}
+		blockNumber = n
+	}
+	if blockNumber > s.latest {
+		return json.RawMessage("null"), nil
+	}
+	return s.block(blockNumber)
+}
+
+func (s *fakeEthService) GetBlockByHash(hash common.Hash, full bool) (json.RawMessage, error) {
+	for blockNumber := uint64(0); blockNumber <= s.latest; blockNumber++ {
+		if s.header(blockNumber).Hash() == hash {
+			return s.block(blockNumber)
+		}
+	}
+	return json.RawMessage("null"), nil
+}
+
+func newFakeExecutionClient(t *testing.T, service *fakeEthService, ttd int64) *ExecutionClient {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	rpcClient := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		rpcClient.Close()
+		server.Stop()
+	})
+	return &ExecutionClient{
+		Eth:   ethclient.NewClient(rpcClient),
+		RPC:   rpcClient,
+		TTD:   TTD{big.NewInt(ttd)},
+		cache: NewDataCache(DefaultDataCacheSize),
+	}
+}
+
+func TestFindTerminalBlockByTTD(t *testing.T) {
+	tests := []struct {
+		name     string
+		latest   uint64
+		ttd      int64
+		expected *uint64
+	}{
+		{
+			name:     "ttd not reached",
+			latest:   100,
+			ttd:      1020,
+			expected: nil,
+		},
+		{
+			name:     "ttd reached by the latest block",
+			latest:   100,
+			ttd:      1010,
+			expected: newUint64(100),
+		},
+		{
+			name:     "ttd reached by the genesis block",
+			latest:   100,
+			ttd:      5,
+			expected: newUint64(0),
+		},
+		{
+			name:     "ttd equal to a block total difficulty",
+			latest:   1000,
+			ttd:      5000,
+			expected: newUint64(499),
+		},
+		{
+			name:     "ttd between two blocks total difficulty",
+			latest:   1000,
+			ttd:      5005,
+			expected: newUint64(500),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &fakeEthService{latest: test.latest}
+			el := newFakeExecutionClient(t, service, test.ttd)
+			header, err := el.findTerminalBlockByTTD()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expected == nil {
+				if header != nil {
+					t.Fatalf("expected no terminal block, got %d", header.Number)
+				}
+				return
+			}
+			if header == nil {
+				t.Fatalf("expected terminal block %d, got none", *test.expected)
+			}
+			if header.Number.Uint64() != *test.expected {
+				t.Fatalf("expected terminal block %d, got %d", *test.expected, header.Number)
+			}
+			// Latest block and terminal header requests, plus the search steps
+			if maxCalls := uint64(2 + bits.Len64(test.latest)); service.calls > maxCalls {
+				t.Fatalf("too many requests for a binary search: %d (max %d)", service.calls, maxCalls)
+			}
+		})
+	}
+}
+
+func TestGetLatestBlockSlotNumberReorg(t *testing.T) {
+	service := &fakeEthService{latest: 10}
+	el := newFakeExecutionClient(t, service, 0)
+	for blockNumber := uint64(5); blockNumber <= 10; blockNumber++ {
+		if _, err := el.GetHeader(blockNumber); err != nil {
+			t.Fatal(err)
+		}
+		el.cache.Add(blockCacheKey(blockNumber), &BlockSummary{})
+	}
+
+	// Blocks 8 to 10 are reorged
+	service.fork(8)
+	service.latest = 11
+	latest, err := el.GetLatestBlockSlotNumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != 11 {
+		t.Fatalf("expected latest block 11, got %d", latest)
+	}
+	for blockNumber := uint64(5); blockNumber <= 11; blockNumber++ {
+		header, err := el.GetHeader(blockNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Hash() != service.header(blockNumber).Hash() {
+			t.Fatalf("block %d: cached header is not canonical", blockNumber)
+		}
+		_, summaryCached := el.cache.Get(blockCacheKey(blockNumber))
+		if expected := blockNumber < 8; summaryCached != expected {
+			t.Fatalf("block %d: expected summary cached %t, got %t", blockNumber, expected, summaryCached)
+		}
+	}
+}
+
+func TestCacheBlock(t *testing.T) {
+	el := newFakeExecutionClient(t, &fakeEthService{}, 0)
+	block := json.RawMessage(`{
+		"number": "0x7",
+		"parentHash": "0x00000000000000000000000000000000000000000000000000000000000000aa",
+		"sha3Uncles": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347",
+		"miner": "0x0000000000000000000000000000000000000000",
+		"stateRoot": "0x00000000000000000000000000000000000000000000000000000000000000bb",
+		"transactionsRoot": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		"receiptsRoot": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421",
+		"logsBloom": "0x` + strings.Repeat("00", 256) + `",
+		"difficulty": "0x0",
+		"gasLimit": "0x1c9c380",
+		"gasUsed": "0xa410",
+		"timestamp": "0x62a0e000",
+		"extraData": "0x",
+		"mixHash": "0x00000000000000000000000000000000000000000000000000000000000000cc",
+		"nonce": "0x0000000000000000",
+		"baseFeePerGas": "0x7",
+		"size": "0x2a1",
+		"transactions": [
+			"0x00000000000000000000000000000000000000000000000000000000000000d1",
+			"0x00000000000000000000000000000000000000000000000000000000000000d2"
+		]
+	}`)
+	header, summary, err := el.cacheBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Number.Uint64() != 7 || header.GasUsed != 42000 || header.BaseFee.Uint64() != 7 {
+		t.Fatalf("unexpected header: number %v, gas used %d, base fee %v", header.Number, header.GasUsed, header.BaseFee)
+	}
+	expectedTransactions := []common.Hash{common.HexToHash("0xd1"), common.HexToHash("0xd2")}
+	if summary.Size != 673 || !reflect.DeepEqual(summary.Transactions, expectedTransactions) {
+		t.Fatalf("unexpected summary: %+v", *summary)
+	}
+	if cached, err := el.GetHeader(7); err != nil || cached.Hash() != header.Hash() {
+		t.Fatalf("header not cached: %v", err)
+	}
+	if cached, err := el.GetBlockSummary(7); err != nil || cached != summary {
+		t.Fatalf("summary not cached: %v", err)
+	}
+
+	if _, _, err := el.cacheBlock(json.RawMessage("null")); err != ethereum.NotFound {
+		t.Fatalf("expected not found for a missing block, got %v", err)
+	}
+}
+
+func TestCacheHeaderReplacement(t *testing.T) {
+	service := &fakeEthService{latest: 10}
+	el := newFakeExecutionClient(t, service, 0)
+	if _, err := el.GetBlockSummary(8); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same header again keeps the summary
+	el.cacheHeader(service.header(8))
+	if _, ok := el.cache.Get(blockCacheKey(8)); !ok {
+		t.Fatal("summary removed by the same header")
+	}
+
+	// A different header for the same number means a reorg
+	service.fork(8)
+	el.cacheHeader(service.header(8))
+	if _, ok := el.cache.Get(blockCacheKey(8)); ok {
+		t.Fatal("summary of the reorged block still cached")
+	}
+	header, err := el.GetHeader(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Hash() != service.header(8).Hash() {
+		t.Fatal("reorged header still cached")
+	}
+}
+
+func newUint64(v uint64) *uint64 {
+	return &v
+}
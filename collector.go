@@ -0,0 +1,221 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+var (
+	DefaultBeaconCheckDelay    = time.Second * 12
+	DefaultExecutionCheckDelay = time.Second * 12
+	// Check delay used while the client notifies new data on its own
+	DefaultSubscribedCheckDelay = time.Minute
+	// Number of blocks/slots pre-fetched at once while catching up, must fit in the client's cache
+	// along with the data fetched by the probes: each pre-fetched execution block takes two
+	// entries, its header and its summary
+	CatchUpPrefetchSize = uint64(DefaultDataCacheSize / 4)
+)
+
+// DataCollector fetches the data of each block/slot of a client only once, and fans the
+// extracted metric values out to every probe subscribed to the client.
+type DataCollector struct {
+	Client    Client
+	Probes    VerificationProbes
+	IsSyncing bool
+}
+
+type dataPointResult struct {
+	value interface{}
+	err   error
+}
+
+func NewDataCollector(client Client, verifications []Verification) *DataCollector {
+	return &DataCollector{
+		Client: client,
+		Probes: NewVerificationProbes(client, verifications),
+	}
+}
+
+func (dc *DataCollector) Loop(stop <-chan interface{}) {
+	var checkDelay time.Duration
+	if dc.Client.ClientLayer() == Beacon {
+		checkDelay = DefaultBeaconCheckDelay
+	} else if dc.Client.ClientLayer() == Execution {
+		checkDelay = DefaultExecutionCheckDelay
+	}
+	newData := dc.Client.SubscribeNewData()
+	for {
+		delay := checkDelay
+		if dc.Client.IsSubscribed() {
+			delay = DefaultSubscribedCheckDelay
+		}
+		select {
+		case <-stop:
+			return
+		case <-newData:
+		case <-time.After(delay):
+		}
+
+		dc.Collect()
+	}
+}
+
+// Fetch the data points of all the blocks/slots not yet seen by the probes and verify them
+func (dc *DataCollector) Collect() {
+	var ttdBlockSlot *uint64
+	for _, p := range dc.Probes {
+		if p.Verification.PostMerge {
+			var err error
+			ttdBlockSlot, err = dc.Client.UpdateGetTTDBlockSlot()
+			if err != nil {
+				log15.Warn("Error getting ttd block/slot", append(ClientLogCtx(dc.Client), "error", err)...)
+			}
+			break
+		}
+	}
+
+	activeProbes := make(VerificationProbes, 0)
+	for _, p := range dc.Probes {
+		if p.Verification.PostMerge {
+			if ttdBlockSlot == nil {
+				continue
+			}
+			if *ttdBlockSlot > p.PreviousDataPointSlotBlock {
+				p.PreviousDataPointSlotBlock = *ttdBlockSlot
+			}
+		}
+		activeProbes = append(activeProbes, p)
+	}
+
+	latestBlockSlot, err := dc.Client.GetLatestBlockSlotNumber()
+	if err != nil {
+		log15.Warn("Error getting latest block/slot number", append(ClientLogCtx(dc.Client), "error", err)...)
+		return
+	}
+
+	firstBlockSlot := latestBlockSlot + 1
+	for _, p := range activeProbes {
+		if p.PreviousDataPointSlotBlock < firstBlockSlot-1 {
+			firstBlockSlot = p.PreviousDataPointSlotBlock + 1
+		}
+	}
+
+	if firstBlockSlot <= latestBlockSlot {
+		if !dc.IsSyncing && (latestBlockSlot-firstBlockSlot) >= 10 {
+			log15.Info("Syncing data", ClientLogCtx(dc.Client)...)
+			dc.IsSyncing = true
+		}
+		// The probes of each metric are collected concurrently, so that a slow metric does not
+		// delay the others
+		var (
+			prefetcher = &dataPrefetcher{
+				client:     dc.Client,
+				enabled:    dc.IsSyncing,
+				latest:     latestBlockSlot,
+				prefetched: firstBlockSlot - 1,
+			}
+			wg sync.WaitGroup
+		)
+		for metricName, probes := range activeProbes.ByMetric() {
+			wg.Add(1)
+			go func(metricName MetricName, probes VerificationProbes) {
+				defer wg.Done()
+				dc.collectMetric(metricName, probes, firstBlockSlot, latestBlockSlot, prefetcher)
+			}(metricName, probes)
+		}
+		wg.Wait()
+		if dc.IsSyncing {
+			finishedSyncing := true
+			for _, p := range activeProbes {
+				if p.PreviousDataPointSlotBlock < latestBlockSlot {
+					finishedSyncing = false
+					break
+				}
+			}
+			if finishedSyncing {
+				log15.Info("Finished syncing all data", ClientLogCtx(dc.Client)...)
+				dc.IsSyncing = false
+			}
+		}
+	}
+	if !dc.IsSyncing {
+		for _, p := range activeProbes {
+			p.CurrentOutcome, _ = p.Verify()
+		}
+	}
+}
+
+// Fetch the data points of a metric for the probes using it, from the given block/slot up to the
+// latest block/slot
+func (dc *DataCollector) collectMetric(metricName MetricName, probes VerificationProbes, firstBlockSlot uint64, latestBlockSlot uint64, prefetcher *dataPrefetcher) {
+	for currentBlockSlot := firstBlockSlot; currentBlockSlot <= latestBlockSlot; currentBlockSlot++ {
+		prefetcher.prefetch(currentBlockSlot)
+		// The metric is fetched only once per block/slot for all the probes
+		var (
+			dataPoint   *dataPointResult
+			probesAhead = false
+		)
+		for _, p := range probes {
+			if p.PreviousDataPointSlotBlock >= currentBlockSlot {
+				probesAhead = true
+				continue
+			}
+			if p.PreviousDataPointSlotBlock != currentBlockSlot-1 {
+				// Probe is waiting for a previous block/slot
+				continue
+			}
+			if dataPoint == nil {
+				value, err := dc.Client.GetDataPoint(metricName, currentBlockSlot)
+				dataPoint = &dataPointResult{
+					value: value,
+					err:   err,
+				}
+			}
+			if dataPoint.err != nil {
+				if latestBlockSlot-currentBlockSlot <= 64 {
+					log15.Debug("Error during datapoint fetch, will retry", append(ClientLogCtx(dc.Client), "datatype", metricName, "block/slot", currentBlockSlot, "error", dataPoint.err)...)
+					continue
+				}
+				// This data will be considered empty for given block/slot
+				log15.Debug("Unable to fetch datapoint, considered empty", append(ClientLogCtx(dc.Client), "datatype", metricName, "block/slot", currentBlockSlot, "error", dataPoint.err)...)
+			} else if dataPoint.value != nil {
+				// A nil value means the metric has no value for the given block/slot
+				p.DataPointsPerSlotBlock[currentBlockSlot] = dataPoint.value
+			}
+			p.PreviousDataPointSlotBlock = currentBlockSlot
+			probesAhead = true
+		}
+		if !probesAhead {
+			// All probes need to retry the current block/slot
+			return
+		}
+	}
+}
+
+// Pre-fetches the data of the blocks/slots while catching up, CatchUpPrefetchSize blocks/slots at
+// a time, once for all the metrics of the client
+type dataPrefetcher struct {
+	client     Client
+	enabled    bool
+	latest     uint64
+	prefetched uint64
+	l          sync.Mutex
+}
+
+func (pf *dataPrefetcher) prefetch(blockSlot uint64) {
+	pf.l.Lock()
+	defer pf.l.Unlock()
+	if !pf.enabled || blockSlot <= pf.prefetched {
+		return
+	}
+	from := blockSlot
+	pf.prefetched = blockSlot + CatchUpPrefetchSize - 1
+	if pf.prefetched > pf.latest {
+		pf.prefetched = pf.latest
+	}
+	if err := pf.client.PrefetchDataPoints(from, pf.prefetched); err != nil {
+		log15.Debug("Error during data pre-fetch", append(ClientLogCtx(pf.client), "from", from, "to", pf.prefetched, "error", err)...)
+	}
+}
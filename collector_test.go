@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Client which values of the slow metric are only available once the fast metric has been
+// fetched up to the latest block/slot
+type fakeCollectorClient struct {
+	Client
+	latest   uint64
+	fastDone chan interface{}
+	once     sync.Once
+}
+
+const (
+	fakeSlowMetric = MetricName("SlowMetric")
+	fakeFastMetric = MetricName("FastMetric")
+)
+
+func (c *fakeCollectorClient) GetLatestBlockSlotNumber() (uint64, error) {
+	return c.latest, nil
+}
+
+func (c *fakeCollectorClient) GetDataPoint(metricName MetricName, blockSlot uint64) (interface{}, error) {
+	switch metricName {
+	case fakeSlowMetric:
+		select {
+		case <-c.fastDone:
+		case <-time.After(time.Second):
+			return nil, fmt.Errorf("timeout waiting for the fast metric")
+		}
+	case fakeFastMetric:
+		if blockSlot == c.latest {
+			c.once.Do(func() { close(c.fastDone) })
+		}
+	}
+	return blockSlot, nil
+}
+
+func (c *fakeCollectorClient) ClientLayer() ClientLayer {
+	return Execution
+}
+
+func (c *fakeCollectorClient) ClientType() ClientType {
+	return Geth
+}
+
+func (c *fakeCollectorClient) ClientID() int {
+	return 0
+}
+
+func (c *fakeCollectorClient) Node() *Node {
+	return nil
+}
+
+func TestCollectMetricsConcurrently(t *testing.T) {
+	client := &fakeCollectorClient{
+		latest:   3,
+		fastDone: make(chan interface{}),
+	}
+	probes := make(VerificationProbes, 0)
+	for _, metricName := range []MetricName{fakeSlowMetric, fakeFastMetric, fakeFastMetric} {
+		probes = append(probes, &VerificationProbe{
+			Verification:           &Verification{MetricName: metricName},
+			Client:                 client,
+			DataPointsPerSlotBlock: make(DataPoints),
+		})
+	}
+	dc := &DataCollector{
+		Client: client,
+		Probes: probes,
+	}
+	dc.Collect()
+	for _, p := range probes {
+		if p.PreviousDataPointSlotBlock != client.latest {
+			t.Fatalf("%s: expected data points up to %d, got %d", p.Verification.MetricName, client.latest, p.PreviousDataPointSlotBlock)
+		}
+		for blockSlot := uint64(1); blockSlot <= client.latest; blockSlot++ {
+			if value := p.DataPointsPerSlotBlock[blockSlot]; value != blockSlot {
+				t.Fatalf("%s: unexpected data point at %d: %v", p.Verification.MetricName, blockSlot, value)
+			}
+		}
+	}
+}
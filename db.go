@@ -101,6 +101,12 @@ func (dp DataPoints) AggregateUint64(af AggregateFunction, aggregateFuncValue In
 				firstVal = false
 			}
 		}
+	case CountDistinct:
+		distinct := make(map[uint64]struct{})
+		for _, v := range dataPoints {
+			distinct[v] = struct{}{}
+		}
+		aggregatedValue = uint64(len(distinct))
 	default:
 		return aggregatedValue, fmt.Errorf("invalid aggregate function for uint64: %s", af)
 	}
@@ -182,6 +188,12 @@ func (dp DataPoints) AggregateBigInt(af AggregateFunction, aggregateFuncValue In
 				firstVal = false
 			}
 		}
+	case CountDistinct:
+		distinct := make(map[string]struct{})
+		for _, v := range dataPoints {
+			distinct[v.String()] = struct{}{}
+		}
+		aggregatedValue = big.NewInt(int64(len(distinct)))
 	default:
 		return nil, fmt.Errorf("invalid aggregate function for bigInt: %s", af)
 	}
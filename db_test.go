@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestAggregateCountDistinct(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataPoints DataPoints
+		expected   uint64
+	}{
+		{
+			name:       "no data points",
+			dataPoints: DataPoints{},
+			expected:   0,
+		},
+		{
+			name:       "same value",
+			dataPoints: DataPoints{1: uint64(7), 2: uint64(7), 3: uint64(7)},
+			expected:   1,
+		},
+		{
+			name:       "repeated values",
+			dataPoints: DataPoints{1: uint64(7), 2: uint64(0), 3: uint64(7), 4: uint64(9)},
+			expected:   3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := test.dataPoints.AggregateUint64(CountDistinct, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != test.expected {
+				t.Fatalf("expected %d distinct values, got %d", test.expected, count)
+			}
+		})
+	}
+}
+
+func TestAggregateBigIntCountDistinct(t *testing.T) {
+	large, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		name       string
+		dataPoints DataPoints
+		expected   int64
+	}{
+		{
+			name:       "no data points",
+			dataPoints: DataPoints{},
+			expected:   0,
+		},
+		{
+			name:       "equal values of different instances",
+			dataPoints: DataPoints{1: big.NewInt(7), 2: big.NewInt(7)},
+			expected:   1,
+		},
+		{
+			name:       "repeated values",
+			dataPoints: DataPoints{1: big.NewInt(7), 2: large, 3: new(big.Int).Set(large), 4: big.NewInt(0)},
+			expected:   3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := test.dataPoints.AggregateBigInt(CountDistinct, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count.Cmp(big.NewInt(test.expected)) != 0 {
+				t.Fatalf("expected %d distinct values, got %v", test.expected, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Get the value at a path into a JSON document.
+// The path is a dot separated list of object keys and array indexes, e.g. `transactions.0.gas`,
+// an empty path returns the whole document.
+// Numbers are returned as json.Number to keep their precision.
+func JSONPathValue(doc []byte, path string) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return value, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return nil, fmt.Errorf("key not found: %s", key)
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array index: %s", key)
+			}
+			if index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("array index out of range: %d", index)
+			}
+			value = v[index]
+		default:
+			return nil, fmt.Errorf("unable to get %s from a non-container value", key)
+		}
+	}
+	return value, nil
+}
+
+// Convert a value obtained from a JSON document to the given data type.
+// Strings can be decimal or hex numbers, and booleans are converted to 1 or 0.
+func JSONValueToDataType(value interface{}, dataType DataType) (interface{}, error) {
+	var input InputValue
+	switch v := value.(type) {
+	case string:
+		input = InputValue(v)
+	case json.Number:
+		input = InputValue(v.String())
+	case bool:
+		input = "0"
+		if v {
+			input = "1"
+		}
+	case nil:
+		return nil, fmt.Errorf("null value")
+	default:
+		return nil, fmt.Errorf("not a number: %v", v)
+	}
+	switch dataType {
+	case Uint64:
+		return input.ToUint64()
+	case BigInt:
+		return input.ToBigInt()
+	}
+	return nil, fmt.Errorf("invalid data type: %s", dataType)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestJSONPathValue(t *testing.T) {
+	doc := []byte(`{
+		"number": "0x10",
+		"gasUsed": 21000,
+		"big": 123456789012345678901234567890,
+		"synced": true,
+		"empty": null,
+		"transactions": [
+			{"gas": "0x5208"},
+			{"gas": "0xa410", "logs": [1, 2]}
+		]
+	}`)
+	tests := []struct {
+		path     string
+		expected interface{}
+		err      bool
+	}{
+		{path: "number", expected: "0x10"},
+		{path: "gasUsed", expected: json.Number("21000")},
+		{path: "big", expected: json.Number("123456789012345678901234567890")},
+		{path: "synced", expected: true},
+		{path: "empty", expected: nil},
+		{path: "transactions.1.gas", expected: "0xa410"},
+		{path: "transactions.1.logs.0", expected: json.Number("1")},
+		{path: "missing", err: true},
+		{path: "transactions.2.gas", err: true},
+		{path: "transactions.-1.gas", err: true},
+		{path: "transactions.first.gas", err: true},
+		{path: "number.value", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			value, err := JSONPathValue(doc, test.path)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Fatalf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+
+	if value, err := JSONPathValue([]byte(`"0x1"`), ""); err != nil || value != "0x1" {
+		t.Fatalf("unexpected whole document value: %v, %v", value, err)
+	}
+	if _, err := JSONPathValue([]byte(`{`), "number"); err == nil {
+		t.Fatal("expected an error for an invalid document")
+	}
+}
+
+func TestJSONValueToDataType(t *testing.T) {
+	bigValue, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		name     string
+		value    interface{}
+		dataType DataType
+		expected interface{}
+		err      bool
+	}{
+		{name: "hex string", value: "0x10", dataType: Uint64, expected: uint64(16)},
+		{name: "decimal string", value: "16", dataType: Uint64, expected: uint64(16)},
+		{name: "number", value: json.Number("21000"), dataType: Uint64, expected: uint64(21000)},
+		{name: "big number", value: json.Number("123456789012345678901234567890"), dataType: BigInt, expected: bigValue},
+		{name: "true", value: true, dataType: Uint64, expected: uint64(1)},
+		{name: "false", value: false, dataType: Uint64, expected: uint64(0)},
+		{name: "null", value: nil, dataType: Uint64, err: true},
+		{name: "object", value: map[string]interface{}{}, dataType: Uint64, err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := JSONValueToDataType(test.value, test.dataType)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, value)
+			}
+		})
+	}
+}
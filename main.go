@@ -1,16 +1,22 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"math/big"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"gopkg.in/inconshreveable/log15.v2"
 )
 
@@ -27,41 +33,105 @@ func (t *TTD) Set(val string) error {
 	return nil
 }
 
+func (t *TTD) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	return t.Set(str)
+}
+
+func (t TTD) String() string {
+	if t.Int == nil {
+		return "-"
+	}
+	return t.Int.String()
+}
+
+type BlockHash struct {
+	common.Hash
+}
+
+func (h *BlockHash) Set(val string) error {
+	b, err := hexutil.Decode(val)
+	if err != nil {
+		return err
+	}
+	if len(b) != common.HashLength {
+		return fmt.Errorf("invalid hash length: %s", val)
+	}
+	h.Hash = common.BytesToHash(b)
+	return nil
+}
+
 type Verifier struct {
-	Clients   Clients
-	Probes    VerificationProbes
-	WaitGroup sync.WaitGroup
-	StopChan  chan interface{}
+	Clients    Clients
+	Collectors []*DataCollector
+	Probes     VerificationProbes
+	WaitGroup  sync.WaitGroup
+	StopChan   chan interface{}
 }
 
 func (p *Verifier) StartProbes() {
-	for _, vp := range p.Probes {
-		vp := vp
+	for _, dc := range p.Collectors {
+		dc := dc
 		p.WaitGroup.Add(1)
 		go func() {
 			defer p.WaitGroup.Done()
-			vp.Loop(p.StopChan)
+			dc.Loop(p.StopChan)
 		}()
 	}
 }
 
 func (p *Verifier) WrapUp() bool {
-	allSuccess := true
+	var (
+		allSuccess = true
+		passed     = make(map[Client]int)
+		failed     = make(map[Client]int)
+	)
+	logOutcome := func(cl Client, verificationName string, vOut VerificationOutcome) {
+		var f func(string, ...interface{})
+		if vOut.Success {
+			f = log15.Info
+			passed[cl]++
+		} else {
+			f = log15.Crit
+			failed[cl]++
+			allSuccess = false
+		}
+		f(verificationName, append(ClientLogCtx(cl), "pass", vOut.Success, "extra", vOut.Message)...)
+	}
 	for _, vp := range p.Probes {
 		if vOut, err := vp.Verify(); err != nil {
-			log15.Crit("Unable to perform verification", "client", vp.Client.ClientType(), "clientID", vp.Client.ClientID(), "verification", vp.Verification.VerificationName)
+			log15.Crit("Unable to perform verification", append(ClientLogCtx(vp.Client), "verification", vp.Verification.VerificationName)...)
+			failed[vp.Client]++
 			allSuccess = false
 		} else {
-			var f func(string, ...interface{})
-			if vOut.Success {
-				f = log15.Info
-			} else {
-				f = log15.Crit
-				allSuccess = false
-			}
-			f(vp.Verification.VerificationName, "client", vp.Client.ClientType(), "clientID", vp.Client.ClientID(), "pass", vOut.Success, "extra", vOut.Message)
+			logOutcome(vp.Client, vp.Verification.VerificationName, vOut)
+		}
+	}
+	for _, dc := range p.Collectors {
+		cl := dc.Client
+		for _, cv := range cl.ClientVerifications() {
+			logOutcome(cl, cv.VerificationName, cv.Outcome)
 		}
 	}
+
+	// Summary per client, grouped by node
+	rows := make([][]string, 0)
+	for _, dc := range p.Collectors {
+		cl := dc.Client
+		nodeName := "-"
+		if node := cl.Node(); node != nil {
+			nodeName = node.String()
+		}
+		rows = append(rows, []string{nodeName, fmt.Sprintf("%s-%d", cl.ClientType(), cl.ClientID()), cl.String(), strconv.Itoa(passed[cl]), strconv.Itoa(failed[cl])})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][0] < rows[j][0]
+	})
+	log15.Info("Verifications summary")
+	fmt.Fprint(os.Stderr, FormatTable([]string{"NODE", "CLIENT", "ENDPOINT", "PASSED", "FAILED"}, rows))
 	return allSuccess
 }
 
@@ -135,19 +205,33 @@ func VerificationsEpochTimeout(clients *Clients, maxEpochs uint64, stop <-chan i
 func main() {
 	var (
 		clients             Clients
+		nodes               Nodes
 		ttdEpochLimit       uint64
 		verifEpochLimit     uint64
+		rpcBatchSize        uint64
+		rpcBatchWorkers     uint64
+		skipPreflight       bool
+		participationSource ParticipationSource
 		ttd                 TTD
+		terminalBlockHash   BlockHash
 		verifications       Verifications
 		extra_verifications Verifications
 	)
+	nodes.Clients = &clients
+	flag.Var(&nodes, "node",
+		"Beacon client backed by a specific execution client in the form: <Execution client name>,http://<URL>:<IP>,<Beacon client name>,http://<URL>:<IP>. Parameter can appear multiple times for multiple nodes.")
 	flag.Var(&clients, "client",
-		"Execution/Beacon client URL endpoint to check for the client's status in the form: <Client name>,http://<URL>:<IP>. Parameter can appear multiple times for multiple clients.")
-	flag.Var(&ttd, "ttd", "Value of the Terminal Total Difficulty for the subscribed clients")
+		"Execution/Beacon client URL endpoint to check for the client's status in the form: <Client name>,http://<URL>:<IP>. Execution clients also accept ws://<URL>:<IP> or an IPC path. Parameter can appear multiple times for multiple clients.")
+	flag.Var(&ttd, "ttd", "Value of the Terminal Total Difficulty for the subscribed clients. Default: TERMINAL_TOTAL_DIFFICULTY of the beacon clients' spec")
+	flag.Var(&terminalBlockHash, "terminal-block-hash", "Hash of the terminal block, used to detect the transition instead of the Terminal Total Difficulty. Default: TERMINAL_BLOCK_HASH of the beacon clients' spec")
 	flag.Var(&verifications, "override-verifications", "Path to verifications' YML file to override the defaults")
 	flag.Var(&extra_verifications, "extra-verifications", "Path to verifications' YML file to append to the default verifications")
 	flag.Uint64Var(&ttdEpochLimit, "ttd-epoch-limit", 5, "Max number of epochs to wait for the TTD to be reached. Disable timeout: 0. Default: 5")
 	flag.Uint64Var(&verifEpochLimit, "verif-epoch-limit", 5, "Max number of epochs to wait for successful verifications after the merge has occurred. Disable timeout: 0. Default: 5")
+	flag.Uint64Var(&rpcBatchSize, "rpc-batch-size", DefaultRPCBatchSize, "Number of execution block headers requested per JSON-RPC batch while catching up. Default: 100")
+	flag.Uint64Var(&rpcBatchWorkers, "rpc-batch-workers", DefaultRPCBatchWorkers, "Max number of concurrent JSON-RPC batch requests per execution client while catching up. Default: 4")
+	flag.Var(&participationSource, "participation-source", "Source of the validators' participation used by the attestation performance metrics: Standard (computed from the standard beacon API) or Native (client specific endpoints). Default: Standard")
+	flag.BoolVar(&skipPreflight, "skip-preflight", false, "Skip the check of the clients' reachability and network configuration before starting the verifications")
 	flag.Parse()
 
 	verifier := Verifier{
@@ -155,11 +239,18 @@ func main() {
 		Probes:  make(VerificationProbes, 0),
 	}
 
-	updateAllTTDTimestamps := func(timestamp uint64) {
-		for _, cl := range clients {
-			if cl.ClientLayer() == Beacon {
-				bc := cl.(*BeaconClient)
-				bc.UpdateTTDTimestamp(timestamp)
+	// The terminal block found by a paired execution client is only relevant to the beacon client
+	// of its node, while the one found by any other execution client goes to all unpaired beacon clients
+	updateTerminalBlocks := func(el *ExecutionClient) func(*types.Header) {
+		return func(terminalBlock *types.Header) {
+			if node := el.Node(); node != nil {
+				node.Beacon.UpdateTerminalBlock(terminalBlock)
+				return
+			}
+			for _, bc := range clients.BeaconClients() {
+				if bc.Node() == nil {
+					bc.UpdateTerminalBlock(terminalBlock)
+				}
 			}
 		}
 	}
@@ -184,24 +275,80 @@ func main() {
 		os.Exit(1)
 	}
 
+	if !skipPreflight {
+		if err := Preflight(clients); err != nil {
+			LogCritError("Clients are not ready to be verified", err)
+			os.Exit(1)
+		}
+		log15.Info("Preflight check passed")
+	}
+
+	for _, bc := range clients.BeaconClients() {
+		if err := bc.Init(); err != nil {
+			log15.Crit("Unable to fetch the spec of the beacon client", "client", bc.ClientType(), "clientID", bc.ClientID(), "error", err)
+			os.Exit(1)
+		}
+	}
+
+	mergeConfig, err := ResolveMergeConfig(ttd, terminalBlockHash.Hash, clients.BeaconClients())
+	if err != nil {
+		LogCritError("Unable to resolve the merge parameters", err)
+		os.Exit(1)
+	}
+	ttd = mergeConfig.TTD
+	terminalBlockHashActivationTime, err := mergeConfig.TerminalBlockHashActivationTime(clients.BeaconClients())
+	if err != nil {
+		LogCritError("Unable to resolve the merge parameters", err)
+		os.Exit(1)
+	}
+	log15.Info("Merge parameters", "ttd", mergeConfig.TTD, "terminal_block_hash", mergeConfig.TerminalBlockHash, "terminal_block_hash_activation_epoch", mergeConfig.TerminalBlockHashActivationEpoch, "bellatrix_fork_epoch", mergeConfig.BellatrixForkEpoch)
+
+	unpairedExecutionClients := 0
+	for _, el := range clients.ExecutionClients() {
+		if el.Node() == nil {
+			unpairedExecutionClients++
+		}
+	}
 	for _, cl := range clients {
 		if cl.ClientLayer() == Beacon {
 			bc := cl.(*BeaconClient)
 			bc.TTD = ttd
+			bc.ParticipationSource = participationSource
+			// Paired beacon clients receive the terminal block from their execution client,
+			// unpaired ones from any unpaired execution client
+			bc.TerminalBlockExpected = bc.Node() != nil || unpairedExecutionClients > 0
 		} else if cl.ClientLayer() == Execution {
 			el := cl.(*ExecutionClient)
 			el.TTD = ttd
-			el.UpdateTTDTimestamp = updateAllTTDTimestamps
+			el.TerminalBlockHash = mergeConfig.TerminalBlockHash
+			el.TerminalBlockHashActivationTime = terminalBlockHashActivationTime
+			el.UpdateTerminalBlock = updateTerminalBlocks(el)
+			el.BatchSize = rpcBatchSize
+			el.BatchWorkers = rpcBatchWorkers
 		}
-		clientProbes := NewVerificationProbes(cl, verifications)
-		for _, cp := range clientProbes {
-			cp.AllProbesClient = &clientProbes
+		collector := NewDataCollector(cl, verifications)
+		verifier.Collectors = append(verifier.Collectors, collector)
+		verifier.Probes = append(verifier.Probes, collector.Probes...)
+	}
+
+	// Cross-client verifications require at least two clients of the layer
+	if executionClients := clients.ExecutionClients(); len(executionClients) > 1 {
+		collector := NewDataCollector(NewExecutionNetwork(executionClients), verifications)
+		if len(collector.Probes) > 0 {
+			verifier.Collectors = append(verifier.Collectors, collector)
+			verifier.Probes = append(verifier.Probes, collector.Probes...)
+		}
+	}
+	if beaconClients := clients.BeaconClients(); len(beaconClients) > 1 {
+		collector := NewDataCollector(NewBeaconNetwork(beaconClients), verifications)
+		if len(collector.Probes) > 0 {
+			verifier.Collectors = append(verifier.Collectors, collector)
+			verifier.Probes = append(verifier.Probes, collector.Probes...)
 		}
-		verifier.Probes = append(verifier.Probes, clientProbes...)
 	}
 
-	if verifier.Probes.ExecutionVerifications() == 0 {
-		log15.Crit("At least 1 execution layer verification is required (otherwise we cannot know when the terminal block has been found), exiting")
+	if verifier.Probes.ExecutionVerifications() == 0 && len(clients.BeaconClients()) == 0 {
+		log15.Crit("At least 1 execution layer verification or 1 beacon client is required (otherwise we cannot know when the terminal block has been found), exiting")
 		os.Exit(1)
 	}
 
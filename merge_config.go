@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MergeConfig contains the parameters of the transition of the testnet, obtained from the
+// command line or from the spec of the beacon nodes.
+type MergeConfig struct {
+	TTD                              TTD
+	TerminalBlockHash                common.Hash
+	TerminalBlockHashActivationEpoch uint64
+	BellatrixForkEpoch               uint64
+}
+
+type mergeConfigSource struct {
+	Name   string
+	Config MergeConfig
+}
+
+func (mc *MergeConfig) parameterValues() []string {
+	return []string{
+		mc.TTD.String(),
+		mc.TerminalBlockHash.Hex(),
+		strconv.FormatUint(mc.TerminalBlockHashActivationEpoch, 10),
+		strconv.FormatUint(mc.BellatrixForkEpoch, 10),
+	}
+}
+
+var mergeConfigParameterNames = []string{
+	"TERMINAL_TOTAL_DIFFICULTY",
+	"TERMINAL_BLOCK_HASH",
+	"TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH",
+	"BELLATRIX_FORK_EPOCH",
+}
+
+// Resolve the merge parameters from the command line and the spec of the beacon nodes.
+// An error is returned, along with a table of the differences, if any of the sources disagree.
+func ResolveMergeConfig(flagTTD TTD, flagTerminalBlockHash common.Hash, beaconClients []*BeaconClient) (*MergeConfig, error) {
+	sources := make([]mergeConfigSource, 0)
+	for _, bc := range beaconClients {
+		sources = append(sources, mergeConfigSource{
+			Name: fmt.Sprintf("%s-%d", bc.ClientType(), bc.ClientID()),
+			Config: MergeConfig{
+				TTD:                              bc.Spec.TerminalTotalDifficulty,
+				TerminalBlockHash:                bc.Spec.TerminalBlockHash,
+				TerminalBlockHashActivationEpoch: bc.Spec.TerminalBlockHashActivationEpoch,
+				BellatrixForkEpoch:               bc.Spec.BellatrixForkEpoch,
+			},
+		})
+	}
+
+	var config MergeConfig
+	if len(sources) > 0 {
+		config = sources[0].Config
+	}
+	if flagTTD.Int != nil {
+		config.TTD = flagTTD
+	}
+	if flagTerminalBlockHash != (common.Hash{}) {
+		config.TerminalBlockHash = flagTerminalBlockHash
+	}
+
+	// Compare every parameter of every source against the resolved config
+	var (
+		header   = []string{"", "PARAMETER"}
+		rows     = make([][]string, len(mergeConfigParameterNames))
+		mismatch = false
+	)
+	for i, name := range mergeConfigParameterNames {
+		rows[i] = []string{"", name}
+	}
+	if flagTTD.Int != nil || flagTerminalBlockHash != (common.Hash{}) {
+		header = append(header, "FLAGS")
+		flagValues := []string{"-", "-", "-", "-"}
+		if flagTTD.Int != nil {
+			flagValues[0] = flagTTD.String()
+		}
+		if flagTerminalBlockHash != (common.Hash{}) {
+			flagValues[1] = flagTerminalBlockHash.Hex()
+		}
+		for i := range rows {
+			rows[i] = append(rows[i], flagValues[i])
+		}
+	}
+	expectedValues := config.parameterValues()
+	for _, source := range sources {
+		header = append(header, source.Name)
+		for i, value := range source.Config.parameterValues() {
+			rows[i] = append(rows[i], value)
+			if value != expectedValues[i] {
+				rows[i][0] = "!="
+				mismatch = true
+			}
+		}
+	}
+	if mismatch {
+		return nil, &TableError{
+			Message: "merge parameters mismatch",
+			Table:   FormatTable(header, rows),
+		}
+	}
+
+	if config.TTD.Int == nil && config.TerminalBlockHash == (common.Hash{}) {
+		return nil, fmt.Errorf("unknown terminal total difficulty: use --ttd, --terminal-block-hash or provide a beacon client")
+	}
+	return &config, nil
+}
+
+// Get the start time of TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH, before which the terminal block hash
+// override is ignored, or 0 if the override does not apply or is active since genesis
+func (mc *MergeConfig) TerminalBlockHashActivationTime(beaconClients []*BeaconClient) (uint64, error) {
+	if mc.TerminalBlockHash == (common.Hash{}) || mc.TerminalBlockHashActivationEpoch == 0 {
+		return 0, nil
+	}
+	for _, bc := range beaconClients {
+		genesisTime := bc.GetGenesisTime()
+		if genesisTime == nil {
+			continue
+		}
+		epochDuration := bc.Spec.SlotsPerEpoch * bc.Spec.SecondsPerSlot
+		if epochDuration > 0 && mc.TerminalBlockHashActivationEpoch > (math.MaxUint64-*genesisTime)/epochDuration {
+			// Activation epoch set to FAR_FUTURE_EPOCH
+			return math.MaxUint64, nil
+		}
+		return *genesisTime + mc.TerminalBlockHashActivationEpoch*epochDuration, nil
+	}
+	return 0, fmt.Errorf("unknown genesis time, required to apply TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH")
+}
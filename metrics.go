@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Name of a metric, which is validated once the custom metrics of the verifications are registered
+type MetricName string
+
+// MetricProvider describes a metric that can be collected from the clients and fetches its values
+type MetricProvider interface {
+	// Get the name of the metric as used in the verifications
+	Name() MetricName
+
+	// Get the layer of the clients the metric is collected from
+	Layer() ClientLayer
+
+	// Get the type of the values of the metric
+	DataType() DataType
+
+	// Whether the metric can be obtained from the given client
+	Supports(client Client) bool
+
+	// Get the value of the metric for a specific slot/block number
+	Fetch(client Client, blockSlotNumber uint64) (interface{}, error)
+}
+
+var (
+	metricProviders  = make(map[MetricName]MetricProvider)
+	metricProvidersL sync.Mutex
+)
+
+// Register a metric so it can be used in the verifications, panics if a metric with the same
+// name is already registered
+func RegisterMetric(provider MetricProvider) {
+	if err := AddMetric(provider); err != nil {
+		panic(err)
+	}
+}
+
+// Register a metric, or return an error if a metric with the same name is already registered
+func AddMetric(provider MetricProvider) error {
+	metricProvidersL.Lock()
+	defer metricProvidersL.Unlock()
+	if _, ok := metricProviders[provider.Name()]; ok {
+		return fmt.Errorf("metric already registered: %s", provider.Name())
+	}
+	metricProviders[provider.Name()] = provider
+	return nil
+}
+
+func GetMetricProvider(name MetricName) (MetricProvider, bool) {
+	metricProvidersL.Lock()
+	defer metricProvidersL.Unlock()
+	provider, ok := metricProviders[name]
+	return provider, ok
+}
+
+// Get the names of all the registered metrics, sorted
+func MetricNames() []MetricName {
+	metricProvidersL.Lock()
+	defer metricProvidersL.Unlock()
+	names := make([]MetricName, 0, len(metricProviders))
+	for name := range metricProviders {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Fetch the value of a metric from a client
+func FetchMetric(client Client, name MetricName, blockSlotNumber uint64) (interface{}, error) {
+	provider, ok := GetMetricProvider(name)
+	if !ok || !provider.Supports(client) {
+		return nil, fmt.Errorf("invalid data name: %s", name)
+	}
+	return provider.Fetch(client, blockSlotNumber)
+}
+
+// Metric is a MetricProvider built from its description and a fetch function
+type Metric struct {
+	MetricName     MetricName
+	MetricLayer    ClientLayer
+	MetricDataType DataType
+	// Client types the metric can be obtained from, any client of the layer if empty.
+	// Network clients only provide the metrics that explicitly require them.
+	ClientTypes []ClientType
+	// Whether the metric can only be obtained from a client paired with a client of the
+	// other layer
+	RequiresNode bool
+	FetchFunc    func(client Client, blockSlotNumber uint64) (interface{}, error)
+}
+
+func (m *Metric) Name() MetricName {
+	return m.MetricName
+}
+
+func (m *Metric) Layer() ClientLayer {
+	return m.MetricLayer
+}
+
+func (m *Metric) DataType() DataType {
+	return m.MetricDataType
+}
+
+func (m *Metric) Supports(client Client) bool {
+	if client.ClientLayer() != m.MetricLayer {
+		return false
+	}
+	if m.RequiresNode && client.Node() == nil {
+		return false
+	}
+	if len(m.ClientTypes) == 0 {
+		return !IsNetworkClientType(client.ClientType())
+	}
+	for _, clientType := range m.ClientTypes {
+		if clientType == client.ClientType() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Metric) Fetch(client Client, blockSlotNumber uint64) (interface{}, error) {
+	return m.FetchFunc(client, blockSlotNumber)
+}
+
+// Adapt a fetch function of execution clients to a metric fetch function
+func executionFetch(fetch func(el *ExecutionClient, blockNumber uint64) (interface{}, error)) func(Client, uint64) (interface{}, error) {
+	return func(client Client, blockNumber uint64) (interface{}, error) {
+		el, ok := client.(*ExecutionClient)
+		if !ok {
+			return nil, fmt.Errorf("invalid client for metric: %s", client.ClientType())
+		}
+		return fetch(el, blockNumber)
+	}
+}
+
+// Adapt a fetch function of beacon clients to a metric fetch function
+func beaconFetch(fetch func(cl *BeaconClient, slotNumber uint64) (interface{}, error)) func(Client, uint64) (interface{}, error) {
+	return func(client Client, slotNumber uint64) (interface{}, error) {
+		cl, ok := client.(*BeaconClient)
+		if !ok {
+			return nil, fmt.Errorf("invalid client for metric: %s", client.ClientType())
+		}
+		return fetch(cl, slotNumber)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	BeaconBlockCount                  MetricName = "BeaconBlockCount"
+	FinalizedEpoch                    MetricName = "FinalizedEpoch"
+	JustifiedEpoch                    MetricName = "JustifiedEpoch"
+	SlotAttestations                  MetricName = "SlotAttestations"
+	SlotAttestationsPercentage        MetricName = "SlotAttestationsPercentage"
+	EpochAttestationPerformance       MetricName = "EpochAttestationPerformance"
+	EpochTargetAttestationPerformance MetricName = "EpochTargetAttestationPerformance"
+	EpochSourceAttestationPerformance MetricName = "EpochSourceAttestationPerformance"
+	SyncParticipationCount            MetricName = "SyncParticipationCount"
+	SyncParticipationPercentage       MetricName = "SyncParticipationPercentage"
+	ExecutionPayloadMismatch          MetricName = "ExecutionPayloadMismatch"
+	MissedSlot                        MetricName = "MissedSlot"
+	EpochMissedSlotPercentage         MetricName = "EpochMissedSlotPercentage"
+	ProposerConsecutiveMissedDuties   MetricName = "ProposerConsecutiveMissedDuties"
+	UnexpectedProposer                MetricName = "UnexpectedProposer"
+	BeaconPayloadPresent              MetricName = "BeaconPayloadPresent"
+	BeaconPayloadTransactionCount     MetricName = "BeaconPayloadTransactionCount"
+	BeaconPayloadGasUsed              MetricName = "BeaconPayloadGasUsed"
+	PrevRandaoMismatch                MetricName = "PrevRandaoMismatch"
+)
+
+// Return `1` for each change of a checkpoint root at the start of an epoch
+func checkpointRootChanged(cl *BeaconClient, slotNumber uint64, root func(*StateFinalityCheckpoints) common.Hash) (interface{}, error) {
+	if slotNumber == 0 || (slotNumber%cl.Spec.SlotsPerEpoch) != 0 {
+		return uint64(0), nil
+	}
+
+	currentSlotFinalityCheckpoint, err := cl.GetFinalityCheckpoints(slotNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if root(currentSlotFinalityCheckpoint) == (common.Hash{}) {
+		return uint64(0), nil
+	}
+
+	prevSlotFinalityCheckpoint, err := cl.GetFinalityCheckpoints(slotNumber - 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if root(prevSlotFinalityCheckpoint) != root(currentSlotFinalityCheckpoint) {
+		return uint64(1), nil
+	}
+	return uint64(0), nil
+}
+
+// Get the execution payload of the block at the given slot, and whether the slot has a block
+func executionPayloadAtSlot(cl *BeaconClient, slotNumber uint64) (*ExecutionPayload, bool, error) {
+	block, err := cl.GetBeaconBlock(slotNumber)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return block.BlockMessage.Body.ExecutionPayload, true, nil
+}
+
+func init() {
+	RegisterMetric(&Metric{
+		MetricName:     BeaconBlockCount,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			isMissed, err := cl.IsSlotMissed(slotNumber)
+			if err != nil {
+				return nil, err
+			}
+			if isMissed {
+				return uint64(0), nil
+			}
+			return uint64(1), nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     FinalizedEpoch,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			return checkpointRootChanged(cl, slotNumber, func(c *StateFinalityCheckpoints) common.Hash {
+				return c.Finalized.Root
+			})
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     JustifiedEpoch,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			return checkpointRootChanged(cl, slotNumber, func(c *StateFinalityCheckpoints) common.Hash {
+				return c.Justified.Root
+			})
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     SlotAttestations,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			return cl.GetAttestationCountForSlot(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     SlotAttestationsPercentage,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			committeeSize, err := cl.GetSlotCommitteeSize(slotNumber)
+			if err != nil {
+				return uint64(0), err
+			}
+			if committeeSize == 0 {
+				return committeeSize, fmt.Errorf("empty committee for slot %d", slotNumber)
+			}
+
+			slotAttestations, err := cl.GetAttestationCountForSlot(slotNumber)
+			if err != nil {
+				return uint64(0), err
+			}
+			return (slotAttestations * 100) / committeeSize, nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     EpochAttestationPerformance,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			participation, err := cl.GetEpochParticipation(cl.EpochForSlot(slotNumber))
+			if err != nil {
+				return nil, err
+			}
+			return (participation.HeadAttestingGwei * 100) / participation.ActiveGwei, nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     EpochTargetAttestationPerformance,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			participation, err := cl.GetEpochParticipation(cl.EpochForSlot(slotNumber))
+			if err != nil {
+				return nil, err
+			}
+			return (participation.TargetAttestingGwei * 100) / participation.ActiveGwei, nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     EpochSourceAttestationPerformance,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			participation, err := cl.GetEpochParticipation(cl.EpochForSlot(slotNumber))
+			if err != nil {
+				return nil, err
+			}
+			return (participation.SourceAttestingGwei * 100) / participation.ActiveGwei, nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     SyncParticipationCount,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			return cl.GetSyncParticipationCountAtSlot(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     SyncParticipationPercentage,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			return cl.GetSyncParticipationPercentageAtSlot(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     ExecutionPayloadMismatch,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		// The payload is compared with the execution client of the node
+		RequiresNode: true,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			return cl.GetExecutionPayloadMismatchAtSlot(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     MissedSlot,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			isMissed, err := cl.IsSlotMissed(slotNumber)
+			if err != nil {
+				return nil, err
+			}
+			if isMissed {
+				return uint64(1), nil
+			}
+			return uint64(0), nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     EpochMissedSlotPercentage,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			// Single value per epoch, at its last slot
+			if (slotNumber+1)%cl.Spec.SlotsPerEpoch != 0 {
+				return nil, nil
+			}
+			missed, err := cl.GetEpochMissedSlots(cl.EpochForSlot(slotNumber))
+			if err != nil {
+				return nil, err
+			}
+			return (missed * 100) / cl.Spec.SlotsPerEpoch, nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     ProposerConsecutiveMissedDuties,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			if available, err := cl.ProposerDutiesAvailable(slotNumber); err != nil || !available {
+				return nil, err
+			}
+			return cl.GetProposerConsecutiveMissedDuties(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     UnexpectedProposer,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			if available, err := cl.ProposerDutiesAvailable(slotNumber); err != nil || !available {
+				return nil, err
+			}
+			return cl.GetUnexpectedProposerAtSlot(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     BeaconPayloadPresent,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			payload, found, err := executionPayloadAtSlot(cl, slotNumber)
+			if err != nil || !found {
+				return nil, err
+			}
+			if payload.IsDefault() {
+				return uint64(0), nil
+			}
+			return uint64(1), nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     BeaconPayloadTransactionCount,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			payload, found, err := executionPayloadAtSlot(cl, slotNumber)
+			if err != nil || !found || payload.IsDefault() {
+				return nil, err
+			}
+			return uint64(len(payload.Transactions)), nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     BeaconPayloadGasUsed,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			payload, found, err := executionPayloadAtSlot(cl, slotNumber)
+			if err != nil || !found || payload.IsDefault() {
+				return nil, err
+			}
+			return payload.GasUsed, nil
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     PrevRandaoMismatch,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		// The prev_randao is compared with the mixHash of the execution client of the node
+		RequiresNode: true,
+		FetchFunc: beaconFetch(func(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+			payload, found, err := executionPayloadAtSlot(cl, slotNumber)
+			if err != nil || !found || payload.IsDefault() {
+				return nil, err
+			}
+			// The block is fetched by hash, the block of the same number might have been reorged
+			header, err := cl.Node().Execution.GetHeaderByHash(payload.BlockNumber, payload.BlockHash)
+			if err != nil {
+				return nil, err
+			}
+			if header.MixDigest != payload.PrevRandao {
+				log15.Warn("PrevRandao mismatch", append(ClientLogCtx(cl), "slot", slotNumber, "block", payload.BlockNumber, "prevRandao", payload.PrevRandao, "mixHash", header.MixDigest)...)
+				return uint64(1), nil
+			}
+			return uint64(0), nil
+		}),
+	})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Placeholders replaced in the path of a BeaconMetric
+const (
+	beaconMetricSlotPlaceholder  = "{slot}"
+	beaconMetricEpochPlaceholder = "{epoch}"
+)
+
+// Reduction applied to the value found in the response of a BeaconMetric
+type BeaconMetricReduce uint64
+
+const (
+	// Numeric value, decimal or hex
+	ReduceValue BeaconMetricReduce = iota
+	// Number of elements of an array or object
+	ReduceLength
+	// Number of set bits of a hex encoded bitvector
+	ReduceBitCount
+	// Number of set bits of a hex encoded bitlist, excluding its length bit
+	ReduceBitlistCount
+	// 1 if the value differs from the value at the previous slot, 0 otherwise
+	ReduceChanged
+)
+
+var BeaconMetricReduces = map[string]BeaconMetricReduce{
+	"Value":        ReduceValue,
+	"Length":       ReduceLength,
+	"BitCount":     ReduceBitCount,
+	"BitlistCount": ReduceBitlistCount,
+	"Changed":      ReduceChanged,
+}
+
+func (r *BeaconMetricReduce) UnmarshalText(input []byte) error {
+	s := string(input)
+	v, ok := BeaconMetricReduces[s]
+	if !ok {
+		return fmt.Errorf("invalid reduce function: %s", s)
+	}
+	*r = v
+	return nil
+}
+
+func (r BeaconMetricReduce) String() string {
+	for k, v := range BeaconMetricReduces {
+		if r == v {
+			return k
+		}
+	}
+	return ""
+}
+
+// BeaconMetric is a custom beacon metric obtained from a beacon API endpoint on each slot,
+// defined along with a verification.
+type BeaconMetric struct {
+	// Path of the endpoint, the `{slot}` and `{epoch}` placeholders are replaced by the slot
+	// and its epoch
+	Path string `yaml:"Path"`
+	// Path to the value into the `data` of the response, see JSONPathValue
+	DataPath       string             `yaml:"DataPath"`
+	Reduce         BeaconMetricReduce `yaml:"Reduce"`
+	ResultDataType DataType           `yaml:"DataType"`
+}
+
+// Get the metric provider of the beacon metric
+func (m *BeaconMetric) Metric(name MetricName) *Metric {
+	return &Metric{
+		MetricName:     name,
+		MetricLayer:    Beacon,
+		MetricDataType: m.ResultDataType,
+		FetchFunc:      beaconFetch(m.Fetch),
+	}
+}
+
+func (m *BeaconMetric) endpoint(cl *BeaconClient, slotNumber uint64) string {
+	return strings.NewReplacer(
+		beaconMetricSlotPlaceholder, strconv.FormatUint(slotNumber, 10),
+		beaconMetricEpochPlaceholder, strconv.FormatUint(cl.EpochForSlot(slotNumber), 10),
+	).Replace(m.Path)
+}
+
+// Whether the path of the metric depends on the slot. Responses of endpoints that do not, such as
+// the ones of the `head` state, change over time and are not cached.
+func (m *BeaconMetric) slotDependent() bool {
+	return strings.Contains(m.Path, beaconMetricSlotPlaceholder) || strings.Contains(m.Path, beaconMetricEpochPlaceholder)
+}
+
+func (m *BeaconMetric) value(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+	var (
+		data json.RawMessage
+		err  error
+	)
+	if m.slotDependent() {
+		data, err = cl.GetRawData(m.endpoint(cl, slotNumber))
+	} else {
+		data, err = cl.FetchRawData(m.endpoint(cl, slotNumber))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return JSONPathValue(data, m.DataPath)
+}
+
+// Get the value at the latest slot before the given slot that has a value, skipping the missed
+// slots, and whether any was found. Only the SLOTS_PER_EPOCH previous slots are checked.
+func (m *BeaconMetric) previousValue(cl *BeaconClient, slotNumber uint64) (interface{}, bool, error) {
+	for slot := slotNumber; slot > 0 && slotNumber-slot < cl.Spec.SlotsPerEpoch; slot-- {
+		value, err := m.value(cl, slot-1)
+		if err == nil {
+			return value, true, nil
+		}
+		if !IsNotFound(err) {
+			return nil, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+func (m *BeaconMetric) Fetch(cl *BeaconClient, slotNumber uint64) (interface{}, error) {
+	value, err := m.value(cl, slotNumber)
+	if err != nil {
+		return nil, err
+	}
+	var count uint64
+	switch m.Reduce {
+	case ReduceValue:
+		return JSONValueToDataType(value, m.ResultDataType)
+	case ReduceLength:
+		switch v := value.(type) {
+		case []interface{}:
+			count = uint64(len(v))
+		case map[string]interface{}:
+			count = uint64(len(v))
+		default:
+			return nil, fmt.Errorf("unable to get the length of a non-container value")
+		}
+	case ReduceBitCount, ReduceBitlistCount:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("not a hex encoded bitlist or bitvector: %v", value)
+		}
+		var bits AggregationBits
+		if err := bits.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+			return nil, err
+		}
+		if m.Reduce == ReduceBitlistCount {
+			count = bits.CountSetBitlistBits()
+		} else {
+			count = bits.CountSetBits()
+		}
+	case ReduceChanged:
+		prevValue, found, err := m.previousValue(cl, slotNumber)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			break
+		}
+		current, _ := json.Marshal(value)
+		previous, _ := json.Marshal(prevValue)
+		if string(current) != string(previous) {
+			count = 1
+		}
+	default:
+		return nil, fmt.Errorf("invalid reduce function: %s", m.Reduce)
+	}
+	if m.ResultDataType == BigInt {
+		return new(big.Int).SetUint64(count), nil
+	}
+	return count, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBeaconMetricEndpoint(t *testing.T) {
+	cl := &BeaconClient{Spec: Spec{SlotsPerEpoch: 4}}
+	tests := map[string]string{
+		"/eth/v2/beacon/blocks/{slot}":                          "/eth/v2/beacon/blocks/37",
+		"/eth/v1/validator/duties/proposer/{epoch}":             "/eth/v1/validator/duties/proposer/9",
+		"/eth/v1/beacon/states/{slot}/committees?epoch={epoch}": "/eth/v1/beacon/states/37/committees?epoch=9",
+		"/eth/v1/beacon/states/head/finality_checkpoints":       "/eth/v1/beacon/states/head/finality_checkpoints",
+	}
+	for path, expected := range tests {
+		m := &BeaconMetric{Path: path}
+		if endpoint := m.endpoint(cl, 37); endpoint != expected {
+			t.Errorf("expected %s, got %s", expected, endpoint)
+		}
+	}
+}
+
+func TestBeaconMetricFetch(t *testing.T) {
+	block := `{"message":{"slot":"5","body":{
+		"deposits":[{},{}],
+		"sync_aggregate":{"sync_committee_bits":"0x0f01"},
+		"attestations":[{"aggregation_bits":"0x0f"}],
+		"execution_payload":{"gas_used":"21000","base_fee_per_gas":"0x3b9aca00"}
+	}}}`
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, map[string]string{
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 5): block,
+	})
+	tests := []struct {
+		name     string
+		metric   BeaconMetric
+		expected interface{}
+		err      bool
+	}{
+		{
+			name:     "decimal value",
+			metric:   BeaconMetric{DataPath: "message.body.execution_payload.gas_used", Reduce: ReduceValue, ResultDataType: Uint64},
+			expected: uint64(21000),
+		},
+		{
+			name:     "hex value",
+			metric:   BeaconMetric{DataPath: "message.body.execution_payload.base_fee_per_gas", Reduce: ReduceValue, ResultDataType: BigInt},
+			expected: big.NewInt(1000000000),
+		},
+		{
+			name:     "length",
+			metric:   BeaconMetric{DataPath: "message.body.deposits", Reduce: ReduceLength, ResultDataType: Uint64},
+			expected: uint64(2),
+		},
+		{
+			name:     "bitvector",
+			metric:   BeaconMetric{DataPath: "message.body.sync_aggregate.sync_committee_bits", Reduce: ReduceBitCount, ResultDataType: Uint64},
+			expected: uint64(5),
+		},
+		{
+			name:     "bitlist",
+			metric:   BeaconMetric{DataPath: "message.body.attestations.0.aggregation_bits", Reduce: ReduceBitlistCount, ResultDataType: BigInt},
+			expected: big.NewInt(3),
+		},
+		{
+			name:   "length of a non-container",
+			metric: BeaconMetric{DataPath: "message.slot", Reduce: ReduceLength, ResultDataType: Uint64},
+			err:    true,
+		},
+		{
+			name:   "missing data path",
+			metric: BeaconMetric{DataPath: "message.body.missing", Reduce: ReduceValue, ResultDataType: Uint64},
+			err:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.metric.Path = "/eth/v2/beacon/blocks/{slot}"
+			value, err := test.metric.Fetch(cl, 5)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestBeaconMetricChanged(t *testing.T) {
+	var (
+		metric = BeaconMetric{
+			Path:           "/eth/v1/beacon/states/{slot}/finality_checkpoints",
+			DataPath:       "finalized.epoch",
+			Reduce:         ReduceChanged,
+			ResultDataType: Uint64,
+		}
+		checkpoint = func(epoch uint64) string {
+			return fmt.Sprintf(`{"finalized":{"epoch":"%d"}}`, epoch)
+		}
+		endpoint = func(slot uint64) string {
+			return fmt.Sprintf(V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT, slot)
+		}
+	)
+	tests := []struct {
+		name      string
+		responses map[string]string
+		expected  uint64
+		err       bool
+	}{
+		{
+			name:      "unchanged",
+			responses: map[string]string{endpoint(10): checkpoint(1), endpoint(9): checkpoint(1)},
+			expected:  0,
+		},
+		{
+			name:      "changed",
+			responses: map[string]string{endpoint(10): checkpoint(2), endpoint(9): checkpoint(1)},
+			expected:  1,
+		},
+		{
+			name:      "unchanged over missed slots",
+			responses: map[string]string{endpoint(10): checkpoint(1), endpoint(7): checkpoint(1)},
+			expected:  0,
+		},
+		{
+			name:      "changed over missed slots",
+			responses: map[string]string{endpoint(10): checkpoint(2), endpoint(6): checkpoint(1)},
+			expected:  1,
+		},
+		{
+			name:      "previous value beyond the previous epoch",
+			responses: map[string]string{endpoint(10): checkpoint(2), endpoint(5): checkpoint(1)},
+			expected:  0,
+		},
+		{
+			name:      "error of the previous slot",
+			responses: map[string]string{endpoint(10): checkpoint(2), endpoint(9): fakeInternalError},
+			err:       true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, test.responses)
+			value, err := metric.Fetch(cl, 10)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.expected {
+				t.Fatalf("expected %d, got %v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestBeaconMetricUnkeyedPathNotCached(t *testing.T) {
+	responses := map[string]string{
+		"/eth/v1/beacon/states/head/finality_checkpoints": `{"finalized":{"epoch":"1"}}`,
+	}
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, responses)
+	metric := BeaconMetric{
+		Path:           "/eth/v1/beacon/states/head/finality_checkpoints",
+		DataPath:       "finalized.epoch",
+		Reduce:         ReduceValue,
+		ResultDataType: Uint64,
+	}
+	if value, err := metric.Fetch(cl, 10); err != nil || value != uint64(1) {
+		t.Fatalf("unexpected value: %v, %v", value, err)
+	}
+	responses["/eth/v1/beacon/states/head/finality_checkpoints"] = `{"finalized":{"epoch":"2"}}`
+	if value, err := metric.Fetch(cl, 11); err != nil || value != uint64(2) {
+		t.Fatalf("unexpected value: %v, %v", value, err)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func payloadBlock(slot uint64, blockNumber uint64, blockHash common.Hash, prevRandao common.Hash) string {
+	return fmt.Sprintf(`{"message":{"slot":"%d","proposer_index":"0","body":{"execution_payload":{"block_number":"%d","block_hash":"%s","prev_randao":"%s"}}},"signature":"0x"}`, slot, blockNumber, blockHash.Hex(), prevRandao.Hex())
+}
+
+func TestPrevRandaoMismatch(t *testing.T) {
+	service := &fakeEthService{latest: 10}
+	el := newFakeExecutionClient(t, service, 0)
+	// Block 9 is cached before being reorged
+	if _, err := el.GetHeader(9); err != nil {
+		t.Fatal(err)
+	}
+	service.fork(9)
+
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, map[string]string{
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 1): payloadBlock(1, 5, service.header(5).Hash(), common.Hash{}),
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 2): payloadBlock(2, 6, service.header(6).Hash(), common.HexToHash("0x01")),
+		// Slot 3 is missed
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 4): payloadBlock(4, 0, common.Hash{}, common.Hash{}),
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 5): payloadBlock(5, 9, service.header(9).Hash(), fakeForkMixHash),
+	})
+	node := &Node{Execution: el, Beacon: cl}
+	el.node = node
+	cl.node = node
+
+	tests := []struct {
+		name     string
+		slot     uint64
+		expected interface{}
+	}{
+		{name: "matching mix hash", slot: 1, expected: uint64(0)},
+		{name: "mismatching mix hash", slot: 2, expected: uint64(1)},
+		{name: "missed slot", slot: 3, expected: nil},
+		{name: "default payload", slot: 4, expected: nil},
+		{name: "block of the same number reorged", slot: 5, expected: uint64(0)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := FetchMetric(cl, PrevRandaoMismatch, test.slot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, value)
+			}
+		})
+	}
+
+	// The metric is only provided by a beacon client paired with an execution client
+	cl.node = nil
+	if _, err := FetchMetric(cl, PrevRandaoMismatch, 1); err == nil {
+		t.Fatal("expected an error for an unpaired beacon client")
+	}
+}
+
+func TestBeaconPayloadMetrics(t *testing.T) {
+	cl := newFakeBeaconClient(t, uint64(time.Now().Unix())-100, map[string]string{
+		// Block before Bellatrix, without execution payload
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 1): attestationsBlock(1),
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 2): payloadBlock(2, 0, common.Hash{}, common.Hash{}),
+		// Slot 3 is missed
+		fmt.Sprintf(V2_BEACON_BLOCKS_ENDPOINT, 4): `{"message":{"slot":"4","proposer_index":"0","body":{"execution_payload":{
+			"block_number":"7",
+			"block_hash":"0x00000000000000000000000000000000000000000000000000000000000000aa",
+			"gas_used":"42000",
+			"transactions":["0x01","0x02"]
+		}}},"signature":"0x"}`,
+	})
+	tests := []struct {
+		slot     uint64
+		expected map[MetricName]interface{}
+	}{
+		{slot: 1, expected: map[MetricName]interface{}{BeaconPayloadPresent: uint64(0), BeaconPayloadTransactionCount: nil, BeaconPayloadGasUsed: nil}},
+		{slot: 2, expected: map[MetricName]interface{}{BeaconPayloadPresent: uint64(0), BeaconPayloadTransactionCount: nil, BeaconPayloadGasUsed: nil}},
+		{slot: 3, expected: map[MetricName]interface{}{BeaconPayloadPresent: nil, BeaconPayloadTransactionCount: nil, BeaconPayloadGasUsed: nil}},
+		{slot: 4, expected: map[MetricName]interface{}{BeaconPayloadPresent: uint64(1), BeaconPayloadTransactionCount: uint64(2), BeaconPayloadGasUsed: uint64(42000)}},
+	}
+	for _, test := range tests {
+		for metricName, expected := range test.expected {
+			value, err := FetchMetric(cl, metricName, test.slot)
+			if err != nil {
+				t.Fatalf("%s at slot %d: %v", metricName, test.slot, err)
+			}
+			if value != expected {
+				t.Errorf("%s at slot %d: expected %v, got %v", metricName, test.slot, expected, value)
+			}
+		}
+	}
+}
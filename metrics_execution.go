@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	ExecutionBlockCount MetricName = "ExecutionBlockCount"
+	ExecutionBaseFee    MetricName = "ExecutionBaseFee"
+	ExecutionGasUsed    MetricName = "ExecutionGasUsed"
+	ExecutionDifficulty MetricName = "ExecutionDifficulty"
+	ExecutionMixHash    MetricName = "ExecutionMixHash"
+	ExecutionUnclesHash MetricName = "ExecutionUnclesHash"
+	ExecutionNonce      MetricName = "ExecutionNonce"
+
+	ExecutionTransactionCount MetricName = "ExecutionTransactionCount"
+	ExecutionGasLimit         MetricName = "ExecutionGasLimit"
+	ExecutionGasUtilization   MetricName = "ExecutionGasUtilization"
+	ExecutionBlockSize        MetricName = "ExecutionBlockSize"
+
+	ExecutionBlockTime        MetricName = "ExecutionBlockTime"
+	ExecutionSlotMisalignment MetricName = "ExecutionSlotMisalignment"
+	ExecutionBlockSlotGap     MetricName = "ExecutionBlockSlotGap"
+
+	ExecutionBaseFeeMismatch MetricName = "ExecutionBaseFeeMismatch"
+)
+
+// Execution metric obtained from the header of the block
+func headerMetric(name MetricName, dataType DataType, value func(header *types.Header) interface{}) *Metric {
+	return &Metric{
+		MetricName:     name,
+		MetricLayer:    Execution,
+		MetricDataType: dataType,
+		FetchFunc: executionFetch(func(el *ExecutionClient, blockNumber uint64) (interface{}, error) {
+			header, err := el.GetHeader(blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			return value(header), nil
+		}),
+	}
+}
+
+// Execution metric obtained from the summary of the block
+func blockSummaryMetric(name MetricName, value func(summary *BlockSummary) uint64) *Metric {
+	return &Metric{
+		MetricName:     name,
+		MetricLayer:    Execution,
+		MetricDataType: Uint64,
+		FetchFunc: executionFetch(func(el *ExecutionClient, blockNumber uint64) (interface{}, error) {
+			summary, err := el.GetBlockSummary(blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			return value(summary), nil
+		}),
+	}
+}
+
+// Execution metric obtained from the header of the block and the header of its parent, the
+// genesis block has no value
+func parentHeaderMetric(name MetricName, requiresNode bool, value func(el *ExecutionClient, header *types.Header, parent *types.Header) (interface{}, error)) *Metric {
+	return &Metric{
+		MetricName:     name,
+		MetricLayer:    Execution,
+		MetricDataType: Uint64,
+		RequiresNode:   requiresNode,
+		FetchFunc: executionFetch(func(el *ExecutionClient, blockNumber uint64) (interface{}, error) {
+			if blockNumber == 0 {
+				return nil, nil
+			}
+			header, err := el.GetHeader(blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			parent, err := el.GetHeader(blockNumber - 1)
+			if err != nil {
+				return nil, err
+			}
+			return value(el, header, parent)
+		}),
+	}
+}
+
+// Calculate the base fee of a block from its parent header, as specified by EIP-1559
+func CalcBaseFee(parent *types.Header) *big.Int {
+	if parent.BaseFee != nil && parent.GasLimit < params.ElasticityMultiplier {
+		// The gas target of the parent is zero, which go-ethereum would divide by
+		return new(big.Int).Set(parent.BaseFee)
+	}
+	// A parent without base fee precedes the fork block, which gets the initial base fee
+	config := &params.ChainConfig{LondonBlock: common.Big0}
+	if parent.BaseFee == nil {
+		config.LondonBlock = new(big.Int).Add(parent.Number, common.Big1)
+	}
+	return misc.CalcBaseFee(config, parent)
+}
+
+func init() {
+	RegisterMetric(headerMetric(ExecutionBlockCount, Uint64, func(header *types.Header) interface{} {
+		// no error occured, we have a block
+		return uint64(1)
+	}))
+	RegisterMetric(headerMetric(ExecutionBaseFee, BigInt, func(header *types.Header) interface{} {
+		return header.BaseFee
+	}))
+	RegisterMetric(headerMetric(ExecutionGasUsed, Uint64, func(header *types.Header) interface{} {
+		return header.GasUsed
+	}))
+	RegisterMetric(headerMetric(ExecutionDifficulty, BigInt, func(header *types.Header) interface{} {
+		return header.Difficulty
+	}))
+	RegisterMetric(headerMetric(ExecutionMixHash, BigInt, func(header *types.Header) interface{} {
+		return header.MixDigest.Big()
+	}))
+	RegisterMetric(headerMetric(ExecutionUnclesHash, BigInt, func(header *types.Header) interface{} {
+		return header.UncleHash.Big()
+	}))
+	RegisterMetric(headerMetric(ExecutionNonce, Uint64, func(header *types.Header) interface{} {
+		return header.Nonce.Uint64()
+	}))
+	RegisterMetric(blockSummaryMetric(ExecutionTransactionCount, func(summary *BlockSummary) uint64 {
+		return uint64(len(summary.Transactions))
+	}))
+	RegisterMetric(headerMetric(ExecutionGasLimit, Uint64, func(header *types.Header) interface{} {
+		return header.GasLimit
+	}))
+	RegisterMetric(headerMetric(ExecutionGasUtilization, Uint64, func(header *types.Header) interface{} {
+		if header.GasLimit == 0 {
+			return uint64(0)
+		}
+		return (header.GasUsed * 100) / header.GasLimit
+	}))
+	RegisterMetric(blockSummaryMetric(ExecutionBlockSize, func(summary *BlockSummary) uint64 {
+		return uint64(summary.Size)
+	}))
+	RegisterMetric(parentHeaderMetric(ExecutionBlockTime, false, func(el *ExecutionClient, header *types.Header, parent *types.Header) (interface{}, error) {
+		if header.Time < parent.Time {
+			return nil, fmt.Errorf("block timestamp before parent timestamp")
+		}
+		return header.Time - parent.Time, nil
+	}))
+	// The slot of the block is obtained from the beacon client of the node
+	RegisterMetric(&Metric{
+		MetricName:     ExecutionSlotMisalignment,
+		MetricLayer:    Execution,
+		MetricDataType: Uint64,
+		RequiresNode:   true,
+		FetchFunc: executionFetch(func(el *ExecutionClient, blockNumber uint64) (interface{}, error) {
+			header, err := el.GetHeader(blockNumber)
+			if err != nil {
+				return nil, err
+			}
+			cl := el.Node().Beacon
+			if genesisTime := cl.GetGenesisTime(); genesisTime != nil && header.Time < *genesisTime {
+				// Blocks before the beacon chain genesis have no slot
+				return nil, nil
+			}
+			return cl.SlotOffsetAtTime(header.Time)
+		}),
+	})
+	RegisterMetric(parentHeaderMetric(ExecutionBlockSlotGap, true, func(el *ExecutionClient, header *types.Header, parent *types.Header) (interface{}, error) {
+		cl := el.Node().Beacon
+		if genesisTime := cl.GetGenesisTime(); genesisTime != nil && parent.Time < *genesisTime {
+			// Blocks before the beacon chain genesis have no slot
+			return nil, nil
+		}
+		slot, err := cl.SlotAtTime(header.Time)
+		if err != nil {
+			return nil, err
+		}
+		parentSlot, err := cl.SlotAtTime(parent.Time)
+		if err != nil {
+			return nil, err
+		}
+		if slot < parentSlot {
+			return nil, fmt.Errorf("block slot before parent slot")
+		}
+		return slot - parentSlot, nil
+	}))
+	RegisterMetric(parentHeaderMetric(ExecutionBaseFeeMismatch, false, func(el *ExecutionClient, header *types.Header, parent *types.Header) (interface{}, error) {
+		if header.BaseFee == nil {
+			if parent.BaseFee != nil {
+				log15.Warn("Missing base fee", append(ClientLogCtx(el), "block", header.Number)...)
+				return uint64(1), nil
+			}
+			// Block before the fee market activation
+			return nil, nil
+		}
+		if expected := CalcBaseFee(parent); expected.Cmp(header.BaseFee) != 0 {
+			log15.Warn("Base fee mismatch", append(ClientLogCtx(el), "block", header.Number, "expected", expected, "actual", header.BaseFee)...)
+			return uint64(1), nil
+		}
+		return uint64(0), nil
+	}))
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestCalcBaseFee(t *testing.T) {
+	tests := []struct {
+		name          string
+		parentBaseFee *big.Int
+		gasLimit      uint64
+		gasUsed       uint64
+		expected      *big.Int
+	}{
+		{
+			name:          "fork block",
+			parentBaseFee: nil,
+			gasLimit:      30000000,
+			gasUsed:       20000000,
+			expected:      big.NewInt(1000000000),
+		},
+		{
+			name:          "gas used equal to target",
+			parentBaseFee: big.NewInt(1000000000),
+			gasLimit:      30000000,
+			gasUsed:       15000000,
+			expected:      big.NewInt(1000000000),
+		},
+		{
+			name:          "full block",
+			parentBaseFee: big.NewInt(1000000000),
+			gasLimit:      30000000,
+			gasUsed:       30000000,
+			expected:      big.NewInt(1125000000),
+		},
+		{
+			name:          "increase with minimum delta",
+			parentBaseFee: big.NewInt(7),
+			gasLimit:      30000000,
+			gasUsed:       15000001,
+			expected:      big.NewInt(8),
+		},
+		{
+			name:          "empty block",
+			parentBaseFee: big.NewInt(1000000000),
+			gasLimit:      30000000,
+			gasUsed:       0,
+			expected:      big.NewInt(875000000),
+		},
+		{
+			name:          "decrease toward zero",
+			parentBaseFee: big.NewInt(8),
+			gasLimit:      30000000,
+			gasUsed:       0,
+			expected:      big.NewInt(7),
+		},
+		{
+			name:          "zero gas target",
+			parentBaseFee: big.NewInt(1000000000),
+			gasLimit:      1,
+			gasUsed:       1,
+			expected:      big.NewInt(1000000000),
+		},
+		{
+			name:          "decrease below the precision",
+			parentBaseFee: big.NewInt(7),
+			gasLimit:      30000000,
+			gasUsed:       0,
+			expected:      big.NewInt(7),
+		},
+	}
+	for _, test := range tests {
+		parent := &types.Header{
+			Number:   big.NewInt(100),
+			BaseFee:  test.parentBaseFee,
+			GasLimit: test.gasLimit,
+			GasUsed:  test.gasUsed,
+		}
+		if got := CalcBaseFee(parent); got.Cmp(test.expected) != 0 {
+			t.Errorf("%s: expected base fee %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestExecutionBlockMetrics(t *testing.T) {
+	el := newFakeExecutionClient(t, &fakeEthService{latest: 10}, 0)
+	expected := map[MetricName]uint64{
+		ExecutionTransactionCount: 2,
+		ExecutionBlockSize:        505,
+		ExecutionGasLimit:         30000000,
+		ExecutionGasUtilization:   33,
+	}
+	for metricName, expectedValue := range expected {
+		value, err := FetchMetric(el, metricName, 5)
+		if err != nil {
+			t.Fatalf("%s: %v", metricName, err)
+		}
+		if value != expectedValue {
+			t.Errorf("%s: expected %d, got %v", metricName, expectedValue, value)
+		}
+	}
+}
+
+func TestSlotOffsetAtTime(t *testing.T) {
+	genesisTime := uint64(1000)
+	cl := &BeaconClient{
+		Spec:        Spec{SecondsPerSlot: 12},
+		GenesisTime: &genesisTime,
+	}
+	tests := map[uint64]uint64{
+		1000: 0,
+		1005: 5,
+		1012: 0,
+		1025: 1,
+		1035: 11,
+	}
+	for blockTime, expected := range tests {
+		offset, err := cl.SlotOffsetAtTime(blockTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset != expected {
+			t.Errorf("offset at %d: expected %d, got %d", blockTime, expected, offset)
+		}
+	}
+	if _, err := cl.SlotOffsetAtTime(999); err == nil {
+		t.Fatal("expected an error before genesis")
+	}
+}
+
+func TestExecutionSlotMetrics(t *testing.T) {
+	service := &fakeEthService{
+		latest: 5,
+		timestamps: map[uint64]uint64{
+			// Block 1 precedes the beacon chain genesis
+			1: 990,
+			2: 1000,
+			3: 1012,
+			// Slot 2 is missed
+			4: 1036,
+			// Same slot as the parent, out of the slot boundary
+			5: 1041,
+		},
+	}
+	el := newFakeExecutionClient(t, service, 0)
+	cl := newFakeBeaconClient(t, 1000, nil)
+	cl.Spec.SecondsPerSlot = 12
+	node := &Node{Execution: el, Beacon: cl}
+	el.node = node
+	cl.node = node
+
+	tests := []struct {
+		blockNumber  uint64
+		misalignment interface{}
+		blockSlotGap interface{}
+	}{
+		{blockNumber: 1, misalignment: nil, blockSlotGap: nil},
+		{blockNumber: 2, misalignment: uint64(0), blockSlotGap: nil},
+		{blockNumber: 3, misalignment: uint64(0), blockSlotGap: uint64(1)},
+		{blockNumber: 4, misalignment: uint64(0), blockSlotGap: uint64(2)},
+		{blockNumber: 5, misalignment: uint64(5), blockSlotGap: uint64(0)},
+	}
+	for _, test := range tests {
+		misalignment, err := FetchMetric(el, ExecutionSlotMisalignment, test.blockNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if misalignment != test.misalignment {
+			t.Errorf("misalignment of block %d: expected %v, got %v", test.blockNumber, test.misalignment, misalignment)
+		}
+		gap, err := FetchMetric(el, ExecutionBlockSlotGap, test.blockNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gap != test.blockSlotGap {
+			t.Errorf("slot gap of block %d: expected %v, got %v", test.blockNumber, test.blockSlotGap, gap)
+		}
+	}
+
+	blockTime, err := FetchMetric(el, ExecutionBlockTime, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockTime != uint64(24) {
+		t.Fatalf("expected a block time of 24, got %v", blockTime)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	ExecutionChainDisagreement MetricName = "ExecutionChainDisagreement"
+	BeaconHeadDisagreement     MetricName = "BeaconHeadDisagreement"
+	BeaconFinalityDisagreement MetricName = "BeaconFinalityDisagreement"
+)
+
+// Adapt a fetch function of the execution network to a metric fetch function
+func executionNetworkFetch(fetch func(en *ExecutionNetwork, blockNumber uint64) (interface{}, error)) func(Client, uint64) (interface{}, error) {
+	return func(client Client, blockNumber uint64) (interface{}, error) {
+		en, ok := client.(*ExecutionNetwork)
+		if !ok {
+			return nil, fmt.Errorf("invalid client for metric: %s", client.ClientType())
+		}
+		return fetch(en, blockNumber)
+	}
+}
+
+// Adapt a fetch function of the beacon network to a metric fetch function
+func beaconNetworkFetch(fetch func(bn *BeaconNetwork, slotNumber uint64) (interface{}, error)) func(Client, uint64) (interface{}, error) {
+	return func(client Client, slotNumber uint64) (interface{}, error) {
+		bn, ok := client.(*BeaconNetwork)
+		if !ok {
+			return nil, fmt.Errorf("invalid client for metric: %s", client.ClientType())
+		}
+		return fetch(bn, slotNumber)
+	}
+}
+
+func init() {
+	RegisterMetric(&Metric{
+		MetricName:     ExecutionChainDisagreement,
+		MetricLayer:    Execution,
+		MetricDataType: Uint64,
+		// This metric compares the blocks of all the execution clients
+		ClientTypes: []ClientType{ExecutionNetworkClient},
+		FetchFunc: executionNetworkFetch(func(en *ExecutionNetwork, blockNumber uint64) (interface{}, error) {
+			return en.getChainDisagreement(blockNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     BeaconHeadDisagreement,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		// This metric compares the blocks of all the beacon clients
+		ClientTypes: []ClientType{BeaconNetworkClient},
+		FetchFunc: beaconNetworkFetch(func(bn *BeaconNetwork, slotNumber uint64) (interface{}, error) {
+			return bn.getHeadDisagreement(slotNumber)
+		}),
+	})
+	RegisterMetric(&Metric{
+		MetricName:     BeaconFinalityDisagreement,
+		MetricLayer:    Beacon,
+		MetricDataType: Uint64,
+		// This metric compares the finality of all the beacon clients
+		ClientTypes: []ClientType{BeaconNetworkClient},
+		FetchFunc: beaconNetworkFetch(func(bn *BeaconNetwork, slotNumber uint64) (interface{}, error) {
+			return bn.getFinalityDisagreement(slotNumber)
+		}),
+	})
+}
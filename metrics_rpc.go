@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Placeholder replaced by the block number in the parameters of an RPCMetric
+const rpcMetricBlockNumberPlaceholder = "{blockNumber}"
+
+// RPCMetric is a custom execution metric obtained by calling a JSON-RPC method on each block,
+// defined along with a verification.
+type RPCMetric struct {
+	Method string `yaml:"Method"`
+	// Parameters of the method, the `{blockNumber}` placeholder is replaced by the hex encoded
+	// block number
+	Params []interface{} `yaml:"Params"`
+	// Path to the value into the JSON result, see JSONPathValue
+	ResultPath     string   `yaml:"ResultPath"`
+	ResultDataType DataType `yaml:"DataType"`
+}
+
+// Get the metric provider of the RPC metric
+func (m *RPCMetric) Metric(name MetricName) *Metric {
+	return &Metric{
+		MetricName:     name,
+		MetricLayer:    Execution,
+		MetricDataType: m.ResultDataType,
+		FetchFunc:      executionFetch(m.Fetch),
+	}
+}
+
+func (m *RPCMetric) Fetch(el *ExecutionClient, blockNumber uint64) (interface{}, error) {
+	params := make([]interface{}, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = rpcMetricParam(p, hexutil.EncodeUint64(blockNumber))
+	}
+	var result json.RawMessage
+	if err := el.call(&result, m.Method, params...); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 || string(result) == "null" {
+		return nil, ethereum.NotFound
+	}
+	value, err := JSONPathValue(result, m.ResultPath)
+	if err != nil {
+		return nil, err
+	}
+	return JSONValueToDataType(value, m.ResultDataType)
+}
+
+// Replace the block number placeholder in a parameter, and convert the YAML maps to maps that
+// can be encoded to JSON
+func rpcMetricParam(param interface{}, blockNumber string) interface{} {
+	switch p := param.(type) {
+	case string:
+		return strings.ReplaceAll(p, rpcMetricBlockNumberPlaceholder, blockNumber)
+	case []interface{}:
+		converted := make([]interface{}, len(p))
+		for i, v := range p {
+			converted[i] = rpcMetricParam(v, blockNumber)
+		}
+		return converted
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(p))
+		for k, v := range p {
+			converted[fmt.Sprint(k)] = rpcMetricParam(v, blockNumber)
+		}
+		return converted
+	}
+	return param
+}
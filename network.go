@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Disagreements keeps track of the clients which disagreed with the majority of the
+// network, per metric, in order to list them in the verifications' outcome.
+type Disagreements struct {
+	counts map[MetricName]map[string]uint64
+	l      sync.Mutex
+}
+
+func (d *Disagreements) Add(metricName MetricName, clients ...Client) {
+	d.l.Lock()
+	defer d.l.Unlock()
+	if d.counts == nil {
+		d.counts = make(map[MetricName]map[string]uint64)
+	}
+	if d.counts[metricName] == nil {
+		d.counts[metricName] = make(map[string]uint64)
+	}
+	for _, cl := range clients {
+		d.counts[metricName][fmt.Sprintf("%s-%d", cl.ClientType(), cl.ClientID())]++
+	}
+}
+
+// Get the disagreeing clients of a metric along with their disagreements count,
+// empty string if all clients agreed
+func (d *Disagreements) OutcomeDetails(metricName MetricName) string {
+	d.l.Lock()
+	defer d.l.Unlock()
+	counts := d.counts[metricName]
+	if len(counts) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	details := make([]string, len(names))
+	for i, name := range names {
+		details[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+	return fmt.Sprintf("disagreeing clients: %s", strings.Join(details, ", "))
+}
+
+// Split the clients in groups of the same key, and get the clients outside of the largest group.
+// When several groups are the largest there is no majority, and every client is disagreeing.
+func MinorityClients(clients []Client, keys []string) []Client {
+	var (
+		groupSizes    = make(map[string]int)
+		largestSize   int
+		largestGroups int
+		majorityKey   string
+	)
+	for _, k := range keys {
+		groupSizes[k]++
+	}
+	for _, size := range groupSizes {
+		if size > largestSize {
+			largestSize = size
+		}
+	}
+	for k, size := range groupSizes {
+		if size == largestSize {
+			majorityKey = k
+			largestGroups++
+		}
+	}
+	minority := make([]Client, 0)
+	for i, k := range keys {
+		if largestGroups > 1 || k != majorityKey {
+			minority = append(minority, clients[i])
+		}
+	}
+	return minority
+}
+
+// Forward the new data notifications of all the clients to a single notifier
+func fanInNewData(clients []Client, notifier *Notifier, stop <-chan interface{}) {
+	for _, cl := range clients {
+		newData := cl.SubscribeNewData()
+		go func() {
+			for {
+				select {
+				case <-stop:
+					return
+				case <-newData:
+					notifier.Notify()
+				}
+			}
+		}()
+	}
+}
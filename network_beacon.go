@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// BeaconNetwork groups all the beacon clients to verify that they agree on the
+// canonical chain and its finality.
+type BeaconNetwork struct {
+	Clients []*BeaconClient
+
+	// Clients which disagreed with the majority
+	disagreements Disagreements
+
+	// Subscription related
+	newData   Notifier
+	closeChan chan interface{}
+}
+
+func NewBeaconNetwork(clients []*BeaconClient) *BeaconNetwork {
+	bn := &BeaconNetwork{
+		Clients:   clients,
+		closeChan: make(chan interface{}),
+	}
+	fanInNewData(bn.clients(), &bn.newData, bn.closeChan)
+	return bn
+}
+
+func (bn *BeaconNetwork) clients() []Client {
+	clients := make([]Client, len(bn.Clients))
+	for i, cl := range bn.Clients {
+		clients[i] = cl
+	}
+	return clients
+}
+
+func (bn *BeaconNetwork) ClientLayer() ClientLayer {
+	return Beacon
+}
+
+func (bn *BeaconNetwork) ClientVersion() (string, error) {
+	return "", nil
+}
+
+func (bn *BeaconNetwork) GetLatestBlockSlotNumber() (uint64, error) {
+	var latest *uint64
+	for _, cl := range bn.Clients {
+		slot, err := cl.GetLatestBlockSlotNumber()
+		if err != nil {
+			return 0, err
+		}
+		if latest == nil || slot < *latest {
+			latest = &slot
+		}
+	}
+	if latest == nil {
+		return 0, fmt.Errorf("no beacon clients")
+	}
+	return *latest, nil
+}
+
+func (bn *BeaconNetwork) PrefetchDataPoints(fromSlot uint64, toSlot uint64) error {
+	return nil
+}
+
+// The network has reached the TTD once any of its clients has.
+// The transition is detected by the clients' own collectors.
+func (bn *BeaconNetwork) UpdateGetTTDBlockSlot() (*uint64, error) {
+	var ttdSlotNumber *uint64
+	for _, cl := range bn.Clients {
+		if slot := cl.TTDSlotNumber; slot != nil && (ttdSlotNumber == nil || *slot < *ttdSlotNumber) {
+			ttdSlotNumber = slot
+		}
+	}
+	return ttdSlotNumber, nil
+}
+
+func (bn *BeaconNetwork) ClientVerifications() []ClientVerification {
+	return nil
+}
+
+func (bn *BeaconNetwork) GetDataPoint(dataName MetricName, slotNumber uint64) (interface{}, error) {
+	for _, cl := range bn.Clients {
+		cl.awaitSlot(slotNumber, nil)
+	}
+	return FetchMetric(bn, dataName, slotNumber)
+}
+
+// Get the root of the canonical block at the given slot for each client that responded.
+// Clients failing to respond are excluded, at least two responses are required.
+func (bn *BeaconNetwork) getHeadRoots(slotNumber uint64) ([]Client, []string, error) {
+	var (
+		clients = make([]Client, 0, len(bn.Clients))
+		roots   = make([]string, 0, len(bn.Clients))
+	)
+	for _, cl := range bn.Clients {
+		root := "missed"
+		header, err := cl.GetBeaconHeader(slotNumber)
+		if err == nil {
+			root = header.Root
+		} else if !IsNotFound(err) {
+			log15.Debug("Unable to get header, client excluded from the comparison", append(ClientLogCtx(cl), "slot", slotNumber, "error", err)...)
+			continue
+		}
+		clients = append(clients, cl)
+		roots = append(roots, root)
+	}
+	if len(clients) < 2 {
+		return nil, nil, fmt.Errorf("not enough clients returned slot %d", slotNumber)
+	}
+	return clients, roots, nil
+}
+
+// Compare the canonical block at the given slot across all clients:
+// 1 if any client disagrees with the majority, 0 otherwise
+func (bn *BeaconNetwork) getHeadDisagreement(slotNumber uint64) (uint64, error) {
+	clients, roots, err := bn.getHeadRoots(slotNumber)
+	if err != nil {
+		return 0, err
+	}
+	if len(MinorityClients(clients, roots)) == 0 {
+		return 0, nil
+	}
+	// A cached header might be outdated by a reorg, compare the current headers before
+	// counting the disagreement
+	for _, cl := range bn.Clients {
+		cl.InvalidateSlots(slotNumber, slotNumber)
+	}
+	if clients, roots, err = bn.getHeadRoots(slotNumber); err != nil {
+		return 0, err
+	}
+	minority := MinorityClients(clients, roots)
+	if len(minority) == 0 {
+		return 0, nil
+	}
+	log15.Warn("Beacon clients disagree on the canonical block", "slot", slotNumber, "roots", strings.Join(roots, ","))
+	bn.disagreements.Add(BeaconHeadDisagreement, minority...)
+	return 1, nil
+}
+
+// Get the finalized checkpoint at the given slot for each client that responded.
+// Clients failing to respond are excluded, at least two responses are required.
+func (bn *BeaconNetwork) getFinalizedCheckpoints(slotNumber uint64) ([]Client, []string, error) {
+	var (
+		clients     = make([]Client, 0, len(bn.Clients))
+		checkpoints = make([]string, 0, len(bn.Clients))
+	)
+	for _, cl := range bn.Clients {
+		finalityCheckpoints, err := cl.GetFinalityCheckpoints(slotNumber)
+		if err != nil {
+			log15.Debug("Unable to get finality checkpoints, client excluded from the comparison", append(ClientLogCtx(cl), "slot", slotNumber, "error", err)...)
+			continue
+		}
+		clients = append(clients, cl)
+		checkpoints = append(checkpoints, fmt.Sprintf("%d/%s", finalityCheckpoints.Finalized.Epoch, finalityCheckpoints.Finalized.Root))
+	}
+	if len(clients) < 2 {
+		return nil, nil, fmt.Errorf("not enough clients returned the finality checkpoints of slot %d", slotNumber)
+	}
+	return clients, checkpoints, nil
+}
+
+// Compare the finalized checkpoint at the first slot of each epoch across all clients:
+// 1 if any client disagrees with the majority, 0 otherwise
+func (bn *BeaconNetwork) getFinalityDisagreement(slotNumber uint64) (uint64, error) {
+	if slotNumber == 0 || (slotNumber%bn.Clients[0].Spec.SlotsPerEpoch) != 0 {
+		return 0, nil
+	}
+	clients, checkpoints, err := bn.getFinalizedCheckpoints(slotNumber)
+	if err != nil {
+		return 0, err
+	}
+	if len(MinorityClients(clients, checkpoints)) == 0 {
+		return 0, nil
+	}
+	// A cached checkpoint might be outdated by a reorg, compare the current checkpoints
+	// before counting the disagreement
+	for _, cl := range bn.Clients {
+		cl.InvalidateSlots(slotNumber, slotNumber)
+	}
+	if clients, checkpoints, err = bn.getFinalizedCheckpoints(slotNumber); err != nil {
+		return 0, err
+	}
+	minority := MinorityClients(clients, checkpoints)
+	if len(minority) == 0 {
+		return 0, nil
+	}
+	log15.Warn("Beacon clients disagree on the finalized checkpoint", "epoch", bn.Clients[0].EpochForSlot(slotNumber), "checkpoints", strings.Join(checkpoints, ","))
+	bn.disagreements.Add(BeaconFinalityDisagreement, minority...)
+	return 1, nil
+}
+
+func (bn *BeaconNetwork) OutcomeDetails(metricName MetricName) string {
+	return bn.disagreements.OutcomeDetails(metricName)
+}
+
+func (bn *BeaconNetwork) SubscribeNewData() <-chan interface{} {
+	return bn.newData.Subscribe()
+}
+
+// The network only notifies new data on its own if all its clients do
+func (bn *BeaconNetwork) IsSubscribed() bool {
+	for _, cl := range bn.Clients {
+		if !cl.IsSubscribed() {
+			return false
+		}
+	}
+	return true
+}
+
+func (bn *BeaconNetwork) String() string {
+	names := make([]string, len(bn.Clients))
+	for i, cl := range bn.Clients {
+		names[i] = fmt.Sprintf("%s-%d", cl.ClientType(), cl.ClientID())
+	}
+	return strings.Join(names, ",")
+}
+
+func (bn *BeaconNetwork) ClientType() ClientType {
+	return BeaconNetworkClient
+}
+
+func (bn *BeaconNetwork) ClientID() int {
+	return 0
+}
+
+func (bn *BeaconNetwork) Node() *Node {
+	return nil
+}
+
+// Closing the network does not close its clients
+func (bn *BeaconNetwork) Close() error {
+	close(bn.closeChan)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGetHeadDisagreement(t *testing.T) {
+	var (
+		genesisTime = uint64(time.Now().Unix()) - 100
+		header      = func(root string) map[string]string {
+			return map[string]string{
+				fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 5): fmt.Sprintf(`{"root":"%s","canonical":true,"header":{"message":{"slot":"5"}}}`, root),
+			}
+		}
+		missed  = map[string]string{}
+		failing = map[string]string{
+			fmt.Sprintf(V1_BEACON_HEADERS_ENDPOINT, 5): fakeInternalError,
+		}
+	)
+	tests := []struct {
+		name      string
+		responses []map[string]string
+		expected  uint64
+		minority  []int
+		err       bool
+	}{
+		{
+			name:      "all agree",
+			responses: []map[string]string{header("0x01"), header("0x01"), header("0x01")},
+			expected:  0,
+		},
+		{
+			name:      "missed slot against a block",
+			responses: []map[string]string{header("0x01"), header("0x01"), missed},
+			expected:  1,
+			minority:  []int{2},
+		},
+		{
+			name:      "failing client excluded",
+			responses: []map[string]string{header("0x01"), failing, header("0x01"), header("0x02")},
+			expected:  1,
+			minority:  []int{3},
+		},
+		{
+			name:      "failing client excluded from an agreement",
+			responses: []map[string]string{header("0x01"), failing, header("0x01")},
+			expected:  0,
+		},
+		{
+			name:      "single response",
+			responses: []map[string]string{header("0x01"), failing, failing},
+			err:       true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clients := make([]*BeaconClient, len(test.responses))
+			for i, responses := range test.responses {
+				clients[i] = newFakeBeaconClient(t, genesisTime, responses)
+				clients[i].ID = i
+			}
+			bn := &BeaconNetwork{Clients: clients}
+			disagreement, err := bn.getHeadDisagreement(5)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %d", disagreement)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if disagreement != test.expected {
+				t.Fatalf("expected disagreement %d, got %d", test.expected, disagreement)
+			}
+			expectedDetails := ""
+			if len(test.minority) > 0 {
+				minority := make([]Client, len(test.minority))
+				for i, index := range test.minority {
+					minority[i] = clients[index]
+				}
+				var d Disagreements
+				d.Add(BeaconHeadDisagreement, minority...)
+				expectedDetails = d.OutcomeDetails(BeaconHeadDisagreement)
+			}
+			if details := bn.OutcomeDetails(BeaconHeadDisagreement); details != expectedDetails {
+				t.Fatalf("expected %q, got %q", expectedDetails, details)
+			}
+		})
+	}
+}
+
+func TestGetFinalityDisagreement(t *testing.T) {
+	var (
+		genesisTime = uint64(time.Now().Unix()) - 100
+		checkpoints = func(epoch uint64) map[string]string {
+			return map[string]string{
+				fmt.Sprintf(V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT, 8): fmt.Sprintf(`{"finalized":{"epoch":"%d","root":"0x0000000000000000000000000000000000000000000000000000000000000000"}}`, epoch),
+			}
+		}
+		failing = map[string]string{
+			fmt.Sprintf(V1_BEACON_STATE_FINALITY_CHECKPOINTS_ENDPOINT, 8): fakeInternalError,
+		}
+	)
+	clients := []*BeaconClient{
+		newFakeBeaconClient(t, genesisTime, checkpoints(1)),
+		newFakeBeaconClient(t, genesisTime, failing),
+		newFakeBeaconClient(t, genesisTime, checkpoints(1)),
+		newFakeBeaconClient(t, genesisTime, checkpoints(0)),
+	}
+	bn := &BeaconNetwork{Clients: clients}
+	disagreement, err := bn.getFinalityDisagreement(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disagreement != 1 {
+		t.Fatalf("expected disagreement 1, got %d", disagreement)
+	}
+
+	bn = &BeaconNetwork{Clients: clients[:2]}
+	if _, err := bn.getFinalityDisagreement(8); err == nil {
+		t.Fatal("expected an error with a single response")
+	}
+}
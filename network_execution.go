@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+var (
+	// Number of blocks the network stays behind the slowest execution client, to avoid
+	// comparing blocks which are about to be reorged
+	ExecutionNetworkConfirmations = uint64(2)
+)
+
+// ExecutionNetwork groups all the execution clients to verify that they agree on the
+// same chain.
+type ExecutionNetwork struct {
+	Clients []*ExecutionClient
+
+	// Clients which disagreed with the majority
+	disagreements Disagreements
+
+	// Subscription related
+	newData   Notifier
+	closeChan chan interface{}
+}
+
+func NewExecutionNetwork(clients []*ExecutionClient) *ExecutionNetwork {
+	en := &ExecutionNetwork{
+		Clients:   clients,
+		closeChan: make(chan interface{}),
+	}
+	fanInNewData(en.clients(), &en.newData, en.closeChan)
+	return en
+}
+
+func (en *ExecutionNetwork) clients() []Client {
+	clients := make([]Client, len(en.Clients))
+	for i, el := range en.Clients {
+		clients[i] = el
+	}
+	return clients
+}
+
+func (en *ExecutionNetwork) ClientLayer() ClientLayer {
+	return Execution
+}
+
+func (en *ExecutionNetwork) ClientVersion() (string, error) {
+	return "", nil
+}
+
+// The network is at the latest block number known by all the clients
+func (en *ExecutionNetwork) GetLatestBlockSlotNumber() (uint64, error) {
+	var latest *uint64
+	for _, el := range en.Clients {
+		number, err := el.GetLatestBlockSlotNumber()
+		if err != nil {
+			return 0, err
+		}
+		if latest == nil || number < *latest {
+			latest = &number
+		}
+	}
+	if latest == nil || *latest < ExecutionNetworkConfirmations {
+		return 0, nil
+	}
+	return *latest - ExecutionNetworkConfirmations, nil
+}
+
+func (en *ExecutionNetwork) PrefetchDataPoints(fromBlock uint64, toBlock uint64) error {
+	for _, el := range en.Clients {
+		if err := el.PrefetchDataPoints(fromBlock, toBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The network has reached the TTD once any of its clients has
+func (en *ExecutionNetwork) UpdateGetTTDBlockSlot() (*uint64, error) {
+	var ttdBlockNumber *uint64
+	for _, el := range en.Clients {
+		number, err := el.UpdateGetTTDBlockSlot()
+		if err != nil {
+			return nil, err
+		}
+		if number != nil && (ttdBlockNumber == nil || *number < *ttdBlockNumber) {
+			ttdBlockNumber = number
+		}
+	}
+	return ttdBlockNumber, nil
+}
+
+func (en *ExecutionNetwork) ClientVerifications() []ClientVerification {
+	return nil
+}
+
+func (en *ExecutionNetwork) GetDataPoint(dataName MetricName, blockNumber uint64) (interface{}, error) {
+	return FetchMetric(en, dataName, blockNumber)
+}
+
+// Get the block hash and state root of a block for each client that returned its header.
+// Clients failing to return the header are excluded, at least two headers are required.
+func (en *ExecutionNetwork) getChainKeys(blockNumber uint64, getHeader func(*ExecutionClient, uint64) (*types.Header, error)) ([]Client, []string, error) {
+	var (
+		clients = make([]Client, 0, len(en.Clients))
+		keys    = make([]string, 0, len(en.Clients))
+	)
+	for _, el := range en.Clients {
+		header, err := getHeader(el, blockNumber)
+		if err != nil {
+			log15.Debug("Unable to get header, client excluded from the comparison", append(ClientLogCtx(el), "block", blockNumber, "error", err)...)
+			continue
+		}
+		clients = append(clients, el)
+		keys = append(keys, fmt.Sprintf("%s/%s", header.Hash(), header.Root))
+	}
+	if len(clients) < 2 {
+		return nil, nil, fmt.Errorf("not enough clients returned block %d", blockNumber)
+	}
+	return clients, keys, nil
+}
+
+// Compare the block hash and state root of a block across all clients:
+// 1 if any client disagrees with the majority, 0 otherwise
+func (en *ExecutionNetwork) getChainDisagreement(blockNumber uint64) (uint64, error) {
+	clients, keys, err := en.getChainKeys(blockNumber, (*ExecutionClient).GetHeader)
+	if err != nil {
+		return 0, err
+	}
+	if len(MinorityClients(clients, keys)) == 0 {
+		return 0, nil
+	}
+	// A cached header might be outdated by a reorg, compare the current headers before
+	// counting the disagreement
+	if clients, keys, err = en.getChainKeys(blockNumber, (*ExecutionClient).RefreshHeader); err != nil {
+		return 0, err
+	}
+	minority := MinorityClients(clients, keys)
+	if len(minority) == 0 {
+		return 0, nil
+	}
+	log15.Warn("Execution clients disagree on the chain", "block", blockNumber, "blocks", strings.Join(keys, ","))
+	en.disagreements.Add(ExecutionChainDisagreement, minority...)
+	return 1, nil
+}
+
+func (en *ExecutionNetwork) OutcomeDetails(metricName MetricName) string {
+	return en.disagreements.OutcomeDetails(metricName)
+}
+
+func (en *ExecutionNetwork) SubscribeNewData() <-chan interface{} {
+	return en.newData.Subscribe()
+}
+
+// The network only notifies new data on its own if all its clients do
+func (en *ExecutionNetwork) IsSubscribed() bool {
+	for _, el := range en.Clients {
+		if !el.IsSubscribed() {
+			return false
+		}
+	}
+	return true
+}
+
+func (en *ExecutionNetwork) String() string {
+	names := make([]string, len(en.Clients))
+	for i, el := range en.Clients {
+		names[i] = fmt.Sprintf("%s-%d", el.ClientType(), el.ClientID())
+	}
+	return strings.Join(names, ",")
+}
+
+func (en *ExecutionNetwork) ClientType() ClientType {
+	return ExecutionNetworkClient
+}
+
+func (en *ExecutionNetwork) ClientID() int {
+	return 0
+}
+
+func (en *ExecutionNetwork) Node() *Node {
+	return nil
+}
+
+// Closing the network does not close its clients
+func (en *ExecutionNetwork) Close() error {
+	close(en.closeChan)
+	return nil
+}
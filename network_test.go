@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMinorityClients(t *testing.T) {
+	clients := make([]Client, 5)
+	for i := range clients {
+		clients[i] = &ExecutionClient{ID: i}
+	}
+	tests := []struct {
+		name     string
+		keys     []string
+		expected []int
+	}{
+		{
+			name:     "all agree",
+			keys:     []string{"a", "a", "a", "a", "a"},
+			expected: []int{},
+		},
+		{
+			name:     "single disagreeing client",
+			keys:     []string{"a", "a", "b", "a", "a"},
+			expected: []int{2},
+		},
+		{
+			name:     "majority not first",
+			keys:     []string{"a", "b", "b", "c", "b"},
+			expected: []int{0, 3},
+		},
+		{
+			name:     "tie reports every client",
+			keys:     []string{"a", "b", "b", "a", "c"},
+			expected: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:     "all disagree",
+			keys:     []string{"e", "d", "c", "b", "a"},
+			expected: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:     "empty key",
+			keys:     []string{"", "", "", "b", "a"},
+			expected: []int{3, 4},
+		},
+		{
+			name:     "empty key tie",
+			keys:     []string{"", "", "a", "b", "a"},
+			expected: []int{0, 1, 2, 3, 4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			minority := MinorityClients(clients, test.keys)
+			if len(minority) != len(test.expected) {
+				t.Fatalf("expected %d minority clients, got %d", len(test.expected), len(minority))
+			}
+			for i, index := range test.expected {
+				if minority[i] != clients[index] {
+					t.Fatalf("expected client %d, got client %d", index, minority[i].ClientID())
+				}
+			}
+		})
+	}
+}
+
+func TestDisagreementsOutcomeDetails(t *testing.T) {
+	var (
+		d          Disagreements
+		geth       = &ExecutionClient{Type: Geth, ID: 0}
+		nethermind = &ExecutionClient{Type: Nethermind, ID: 1}
+	)
+	if details := d.OutcomeDetails(ExecutionChainDisagreement); details != "" {
+		t.Fatalf("unexpected details without disagreements: %s", details)
+	}
+	d.Add(ExecutionChainDisagreement, nethermind)
+	d.Add(ExecutionChainDisagreement, geth, nethermind)
+	expected := "disagreeing clients: Geth-0 (1), Nethermind-1 (2)"
+	if details := d.OutcomeDetails(ExecutionChainDisagreement); details != expected {
+		t.Fatalf("expected %q, got %q", expected, details)
+	}
+}
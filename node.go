@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node is a beacon client backed by a specific execution client
+type Node struct {
+	ID        int
+	Execution *ExecutionClient
+	Beacon    *BeaconClient
+}
+
+func (n *Node) String() string {
+	return fmt.Sprintf("Node-%d", n.ID)
+}
+
+type Nodes struct {
+	// Clients of all the nodes are also added to this list
+	Clients *Clients
+	Nodes   []*Node
+}
+
+func (ns *Nodes) Set(typeUrls string) error {
+	splitUrls := strings.Split(typeUrls, ",")
+	if len(splitUrls) != 4 {
+		return fmt.Errorf("invalid format")
+	}
+
+	// Both clients are validated before any of them is added to the list
+	if err := validateNodeClientType(splitUrls[0], Execution); err != nil {
+		return err
+	}
+	if err := validateNodeClientType(splitUrls[2], Beacon); err != nil {
+		return err
+	}
+
+	clientCount := len(*ns.Clients)
+	el, err := ns.Clients.AddClient(splitUrls[0], splitUrls[1])
+	if err != nil {
+		return err
+	}
+	bc, err := ns.Clients.AddClient(splitUrls[2], splitUrls[3])
+	if err != nil {
+		el.Close()
+		*ns.Clients = (*ns.Clients)[:clientCount]
+		return err
+	}
+
+	node := &Node{
+		ID:        len(ns.Nodes),
+		Execution: el.(*ExecutionClient),
+		Beacon:    bc.(*BeaconClient),
+	}
+	node.Execution.node = node
+	node.Beacon.node = node
+	ns.Nodes = append(ns.Nodes, node)
+	return nil
+}
+
+// Check that the client type of a node belongs to the expected layer
+func validateNodeClientType(clientTypeStr string, layer ClientLayer) error {
+	clientType, ok := ParseClientTypeString(clientTypeStr)
+	if !ok {
+		return fmt.Errorf("invalid client type: %s", clientTypeStr)
+	}
+	if ClientTypeToLayer[clientType] != layer {
+		if layer == Execution {
+			return fmt.Errorf("not an execution client: %s", clientTypeStr)
+		}
+		return fmt.Errorf("not a beacon client: %s", clientTypeStr)
+	}
+	return nil
+}
+
+func (ns *Nodes) String() string {
+	str := make([]string, 0)
+	for _, n := range ns.Nodes {
+		str = append(str, fmt.Sprintf("%s:%s/%s", n, n.Execution, n.Beacon))
+	}
+	return strings.Join(str, ",")
+}
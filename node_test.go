@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNodesSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeUrls string
+		valid    bool
+	}{
+		{name: "valid node", typeUrls: "geth,http://localhost:8545,lighthouse,http://localhost:5052", valid: true},
+		{name: "invalid format", typeUrls: "geth,http://localhost:8545,lighthouse", valid: false},
+		{name: "unknown client", typeUrls: "geth,http://localhost:8545,unknown,http://localhost:5052", valid: false},
+		{name: "swapped layers", typeUrls: "lighthouse,http://localhost:5052,geth,http://localhost:8545", valid: false},
+		{name: "two execution clients", typeUrls: "geth,http://localhost:8545,nethermind,http://localhost:8546", valid: false},
+		{name: "two beacon clients", typeUrls: "prysm,http://localhost:3500,lighthouse,http://localhost:5052", valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clients Clients
+			nodes := Nodes{Clients: &clients}
+			t.Cleanup(func() {
+				for _, cl := range clients {
+					cl.Close()
+				}
+			})
+			err := nodes.Set(test.typeUrls)
+			if !test.valid {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				// An invalid node leaves no client registered
+				if len(clients) != 0 || len(nodes.Nodes) != 0 {
+					t.Fatalf("expected no client registered, got %d clients and %d nodes", len(clients), len(nodes.Nodes))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(clients) != 2 || len(nodes.Nodes) != 1 {
+				t.Fatalf("expected 2 clients and 1 node, got %d clients and %d nodes", len(clients), len(nodes.Nodes))
+			}
+			node := nodes.Nodes[0]
+			if node.Execution.Node() != node || node.Beacon.Node() != node {
+				t.Fatal("clients not paired with the node")
+			}
+		})
+	}
+}
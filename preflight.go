@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// Timeout for each of the requests performed during the preflight check
+	PreflightTimeout = 10 * time.Second
+)
+
+// Value of a preflight parameter that is not compared
+const preflightUnknown = "-"
+
+// Values reported by a client during the preflight check, keyed by parameter name
+type preflightValues map[string]string
+
+var (
+	executionPreflightParameters = []string{"eth_chainId", "net_version", "genesis_hash"}
+	beaconPreflightParameters    = []string{"genesis_validators_root", "genesis_fork_version"}
+)
+
+func executionPreflight(el *ExecutionClient) (preflightValues, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), PreflightTimeout)
+	defer cancel()
+	values := make(preflightValues)
+	chainID, err := el.Eth.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values["eth_chainId"] = chainID.String()
+	networkID, err := el.Eth.NetworkID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values["net_version"] = networkID.String()
+	genesis, err := el.Eth.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	values["genesis_hash"] = genesis.Hash().Hex()
+	return values, nil
+}
+
+// Get the genesis values and the spec values of a beacon client
+func beaconPreflight(bc *BeaconClient) (preflightValues, preflightValues, error) {
+	values := make(preflightValues)
+	var genesis GenesisResponse
+	if err := bc.sendRequest(GET_REQUEST, V1_BEACON_GENESIS_ENDPOINT, &genesis); err == nil {
+		values["genesis_validators_root"] = genesis.GenesisValidatorsRoot.Hex()
+		values["genesis_fork_version"] = genesis.GenesisForkVersion
+	} else if IsNotFound(err) {
+		// Genesis has not occurred yet
+		values["genesis_validators_root"] = preflightUnknown
+		values["genesis_fork_version"] = preflightUnknown
+	} else {
+		return nil, nil, err
+	}
+	var spec map[string]json.RawMessage
+	if err := bc.sendRequest(GET_REQUEST, V1_CONFIG_SPEC_ENDPOINT, &spec); err != nil {
+		return nil, nil, err
+	}
+	specValues := make(preflightValues)
+	for k, v := range spec {
+		// Clients differ in the case of the hex values
+		specValues[k] = strings.ToLower(string(v))
+	}
+	return values, specValues, nil
+}
+
+// Whether the clients reported different values for a parameter, unknown values are ignored
+func preflightMismatch(parameter string, clientValues []preflightValues) bool {
+	var expected string
+	for _, v := range clientValues {
+		value := v[parameter]
+		if value == preflightUnknown {
+			continue
+		}
+		if expected == "" {
+			expected = value
+		} else if value != expected {
+			return true
+		}
+	}
+	return false
+}
+
+// Check that all the clients are reachable, and that all clients of the same layer are
+// configured for the same network.
+// An error is returned, along with a table of the values reported by each client, if any
+// client is unreachable or any of the values differ.
+func Preflight(clients Clients) error {
+	var (
+		header          = []string{"", "PARAMETER"}
+		executionValues = make([]preflightValues, 0)
+		beaconValues    = make([]preflightValues, 0)
+		specValues      = make([]preflightValues, 0)
+		columns         = make([]preflightValues, 0)
+		failed          = false
+	)
+	for _, cl := range clients {
+		header = append(header, fmt.Sprintf("%s-%d", cl.ClientType(), cl.ClientID()))
+		var values, spec preflightValues
+		version, err := cl.ClientVersion()
+		if err == nil {
+			switch cl := cl.(type) {
+			case *ExecutionClient:
+				values, err = executionPreflight(cl)
+				if err == nil {
+					executionValues = append(executionValues, values)
+				}
+			case *BeaconClient:
+				values, spec, err = beaconPreflight(cl)
+				if err == nil {
+					beaconValues = append(beaconValues, values)
+					specValues = append(specValues, spec)
+					for k, v := range spec {
+						values[k] = v
+					}
+				}
+			}
+		}
+		if err != nil {
+			values = preflightValues{"version": fmt.Sprintf("unreachable: %v", err)}
+			failed = true
+		} else {
+			values["version"] = version
+		}
+		columns = append(columns, values)
+	}
+
+	rows := make([][]string, 0)
+	addRow := func(parameter string, mismatch bool) {
+		row := []string{"", parameter}
+		if mismatch {
+			row[0] = "!="
+			failed = true
+		}
+		for _, values := range columns {
+			value, ok := values[parameter]
+			if !ok {
+				value = preflightUnknown
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+
+	addRow("version", false)
+	for _, p := range executionPreflightParameters {
+		addRow(p, preflightMismatch(p, executionValues))
+	}
+	for _, p := range beaconPreflightParameters {
+		addRow(p, preflightMismatch(p, beaconValues))
+	}
+	// Only the spec values reported by all beacon clients are compared, since clients
+	// may include their own additional values, and only the differing ones are displayed
+	specParameters := make([]string, 0)
+	if len(specValues) > 0 {
+		for k := range specValues[0] {
+			specParameters = append(specParameters, k)
+		}
+	}
+	sort.Strings(specParameters)
+	for _, p := range specParameters {
+		reportedByAll := true
+		for _, v := range specValues {
+			if _, ok := v[p]; !ok {
+				reportedByAll = false
+				break
+			}
+		}
+		if reportedByAll && preflightMismatch(p, specValues) {
+			addRow(p, true)
+		}
+	}
+
+	if failed {
+		return &TableError{
+			Message: "preflight check failed",
+			Table:   FormatTable(header, rows),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreflightMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected bool
+	}{
+		{
+			name:     "single client",
+			values:   []string{"0x1"},
+			expected: false,
+		},
+		{
+			name:     "same values",
+			values:   []string{"0x1", "0x1", "0x1"},
+			expected: false,
+		},
+		{
+			name:     "different values",
+			values:   []string{"0x1", "0x1", "0x2"},
+			expected: true,
+		},
+		{
+			name:     "unknown values ignored",
+			values:   []string{preflightUnknown, "0x1", preflightUnknown, "0x1"},
+			expected: false,
+		},
+		{
+			name:     "different values after an unknown value",
+			values:   []string{preflightUnknown, "0x1", "0x2"},
+			expected: true,
+		},
+		{
+			name:     "all unknown",
+			values:   []string{preflightUnknown, preflightUnknown},
+			expected: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientValues := make([]preflightValues, len(test.values))
+			for i, v := range test.values {
+				clientValues[i] = preflightValues{"eth_chainId": v}
+			}
+			if mismatch := preflightMismatch("eth_chainId", clientValues); mismatch != test.expected {
+				t.Fatalf("expected mismatch %t, got %t", test.expected, mismatch)
+			}
+		})
+	}
+}
+
+func TestPreflightUnreachableBeaconNode(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	var clients Clients
+	if err := clients.Set("lighthouse," + server.URL); err != nil {
+		t.Fatalf("unreachable node rejected before the preflight check: %v", err)
+	}
+	t.Cleanup(func() { clients[0].Close() })
+	err := Preflight(clients)
+	tableErr, ok := err.(*TableError)
+	if !ok {
+		t.Fatalf("expected a table error, got %v", err)
+	}
+	if !strings.Contains(tableErr.Table, "unreachable") {
+		t.Fatalf("expected the node to be reported as unreachable:\n%s", tableErr.Table)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// TableError is an error which details are better displayed as a table
+type TableError struct {
+	Message string
+	Table   string
+}
+
+func (e *TableError) Error() string {
+	return e.Message
+}
+
+// Log a critical error, printing its table if it has one
+func LogCritError(msg string, err error) {
+	log15.Crit(msg, "error", err)
+	if tableErr, ok := err.(*TableError); ok {
+		fmt.Fprint(os.Stderr, tableErr.Table)
+	}
+}
+
+// Format rows of values as a table with aligned columns
+func FormatTable(header []string, rows [][]string) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	w.Write([]byte(strings.Join(header, "\t") + "\n"))
+	for _, row := range rows {
+		w.Write([]byte(strings.Join(row, "\t") + "\n"))
+	}
+	w.Flush()
+	return sb.String()
+}
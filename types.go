@@ -18,6 +18,13 @@ type VerificationOutcome struct {
 	Message string
 }
 
+// ClientVerification is the outcome of a verification performed by a client on its own,
+// outside of the verification probes
+type ClientVerification struct {
+	VerificationName string
+	Outcome          VerificationOutcome
+}
+
 type Verification struct {
 	VerificationName       string            `yaml:"VerificationName"`
 	ClientLayer            ClientLayer       `yaml:"ClientLayer"`
@@ -27,15 +34,16 @@ type Verification struct {
 	AggregateFunctionValue InputValue        `yaml:"AggregateFunctionValue"`
 	PassCriteria           PassCriteria      `yaml:"PassCriteria"`
 	PassValue              InputValue        `yaml:"PassValue"`
+	// Custom metrics defined along with the verification
+	RPCMetric    *RPCMetric    `yaml:"RPCMetric"`
+	BeaconMetric *BeaconMetric `yaml:"BeaconMetric"`
 }
 
 type Verifications []Verification
 
 type VerificationProbe struct {
 	Verification               *Verification
-	AllProbesClient            *VerificationProbes
 	Client                     Client
-	IsSyncing                  bool
 	CurrentOutcome             VerificationOutcome
 	CurrentOutcomeLock         sync.Mutex
 	PreviousDataPointSlotBlock uint64
@@ -56,6 +64,31 @@ func (vs *Verifications) Set(filePath string) error {
 		return err
 	}
 
+	for _, v := range newVerifications {
+		if v.RPCMetric != nil && v.BeaconMetric != nil {
+			return fmt.Errorf("verification %s defines both an RPCMetric and a BeaconMetric", v.VerificationName)
+		}
+		if v.RPCMetric != nil {
+			if v.ClientLayer != Execution {
+				return fmt.Errorf("RPCMetric of verification %s requires the Execution layer", v.VerificationName)
+			}
+			if err := AddMetric(v.RPCMetric.Metric(v.MetricName)); err != nil {
+				return err
+			}
+		}
+		if v.BeaconMetric != nil {
+			if v.ClientLayer != Beacon {
+				return fmt.Errorf("BeaconMetric of verification %s requires the Beacon layer", v.VerificationName)
+			}
+			if err := AddMetric(v.BeaconMetric.Metric(v.MetricName)); err != nil {
+				return err
+			}
+		}
+		if _, ok := GetMetricProvider(v.MetricName); !ok {
+			return fmt.Errorf("invalid data type: %s", v.MetricName)
+		}
+	}
+
 	*vs = append(*vs, newVerifications...)
 	return nil
 }
@@ -82,6 +115,9 @@ const (
 	Prysm
 	Lighthouse
 	GenericBeaconClient
+	// Network clients aggregate all the clients of a layer
+	ExecutionNetworkClient
+	BeaconNetworkClient
 )
 
 var ClientTypeNames = map[string]ClientType{
@@ -99,12 +135,31 @@ var ClientTypeNames = map[string]ClientType{
 	"Beacon":     GenericBeaconClient,
 }
 
+var NetworkClientTypeNames = map[string]ClientType{
+	"ExecutionNetwork": ExecutionNetworkClient,
+	"BeaconNetwork":    BeaconNetworkClient,
+}
+
+func IsNetworkClientType(c ClientType) bool {
+	for _, v := range NetworkClientTypeNames {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
 func (c ClientType) String() string {
 	for k, v := range ClientTypeNames {
 		if v == c {
 			return k
 		}
 	}
+	for k, v := range NetworkClientTypeNames {
+		if v == c {
+			return k
+		}
+	}
 	return ""
 }
 
@@ -151,110 +206,37 @@ var ClientTypeToLayer = map[ClientType]ClientLayer{
 	GenericBeaconClient:    Beacon,
 }
 
-type MetricName uint64
+type DataType uint64
 
 const (
-	// Execution Types
-	ExecutionBlockCount MetricName = iota
-	ExecutionBaseFee
-	ExecutionGasUsed
-	ExecutionDifficulty
-	ExecutionMixHash
-	ExecutionUnclesHash
-	ExecutionNonce
-	// Beacon Types
-	BeaconBlockCount
-	FinalizedEpoch
-	JustifiedEpoch
-	SlotAttestations
-	SlotAttestationsPercentage
-	EpochAttestationPerformance
-	EpochTargetAttestationPerformance
-	SyncParticipationCount
-	SyncParticipationPercentage
+	Uint64 DataType = iota
+	BigInt
 )
 
-var MetricClientTypeRequirements = map[MetricName][]ClientType{
-	EpochAttestationPerformance: {
-		// This metric requires validator_inclusion API from lighthouse
-		Lighthouse,
-	},
-	EpochTargetAttestationPerformance: {
-		// This metric requires validator_inclusion API from lighthouse
-		Lighthouse,
-	},
+var DataTypes = map[string]DataType{
+	"Uint64": Uint64,
+	"BigInt": BigInt,
 }
 
-var MetricNames = map[string]MetricName{
-	// Execution Types
-	"ExecutionBlockCount": ExecutionBlockCount,
-	"ExecutionBaseFee":    ExecutionBaseFee,
-	"ExecutionGasUsed":    ExecutionGasUsed,
-	"ExecutionDifficulty": ExecutionDifficulty,
-	"ExecutionMixHash":    ExecutionMixHash,
-	"ExecutionUnclesHash": ExecutionUnclesHash,
-	"ExecutionNonce":      ExecutionNonce,
-	// Beacon Types
-	"BeaconBlockCount":                  BeaconBlockCount,
-	"FinalizedEpoch":                    FinalizedEpoch,
-	"JustifiedEpoch":                    JustifiedEpoch,
-	"SlotAttestations":                  SlotAttestations,
-	"SlotAttestationsPercentage":        SlotAttestationsPercentage,
-	"EpochAttestationPerformance":       EpochAttestationPerformance,
-	"EpochTargetAttestationPerformance": EpochTargetAttestationPerformance,
-	"SyncParticipationCount":            SyncParticipationCount,
-	"SyncParticipationPercentage":       SyncParticipationPercentage,
-}
-
-func (dn *MetricName) UnmarshalText(input []byte) error {
+func (dt *DataType) UnmarshalText(input []byte) error {
 	s := string(input)
-	v, ok := MetricNames[s]
+	v, ok := DataTypes[s]
 	if !ok {
 		return fmt.Errorf("invalid data type: %s", s)
 	}
-	*dn = v
+	*dt = v
 	return nil
 }
 
-func (dn MetricName) String() string {
-	for k, v := range MetricNames {
-		if dn == v {
+func (dt DataType) String() string {
+	for k, v := range DataTypes {
+		if dt == v {
 			return k
 		}
 	}
 	return ""
 }
 
-type DataType uint64
-
-const (
-	Uint64 DataType = iota
-	BigInt
-)
-
-var DataTypesPerLayer = map[ClientLayer]map[MetricName]DataType{
-	Execution: {
-		ExecutionBlockCount: Uint64,
-		ExecutionBaseFee:    BigInt,
-		ExecutionGasUsed:    Uint64,
-		ExecutionDifficulty: BigInt,
-		ExecutionMixHash:    BigInt,
-		ExecutionUnclesHash: BigInt,
-		ExecutionNonce:      Uint64,
-	},
-	Beacon: {
-		BeaconBlockCount:                  Uint64,
-		FinalizedEpoch:                    Uint64,
-		JustifiedEpoch:                    Uint64,
-		SlotAttestations:                  Uint64,
-		SlotAttestationsPercentage:        Uint64,
-		EpochAttestationPerformance:       Uint64,
-		EpochTargetAttestationPerformance: Uint64,
-		SyncParticipationCount:            Uint64,
-		SyncParticipationPercentage:       Uint64,
-	},
-}
-
 type AggregateFunction uint64
 
 const (
@@ -266,17 +248,19 @@ const (
 	Percentage
 	Min
 	Max
+	CountDistinct
 )
 
 var AggregateFunctions = map[string]AggregateFunction{
-	"Count":        Count,
-	"CountUnequal": CountUnequal,
-	"CountEqual":   CountEqual,
-	"Average":      Average,
-	"Sum":          Sum,
-	"Percentage":   Percentage,
-	"Min":          Min,
-	"Max":          Max,
+	"Count":         Count,
+	"CountUnequal":  CountUnequal,
+	"CountEqual":    CountEqual,
+	"Average":       Average,
+	"Sum":           Sum,
+	"Percentage":    Percentage,
+	"Min":           Min,
+	"Max":           Max,
+	"CountDistinct": CountDistinct,
 }
 
 func (af *AggregateFunction) UnmarshalText(input []byte) error {
@@ -2,58 +2,30 @@ package main
 
 import (
 	"fmt"
-	"time"
-
-	"gopkg.in/inconshreveable/log15.v2"
-)
-
-var (
-	DefaultBeaconCheckDelay    = time.Second * 12
-	DefaultExecutionCheckDelay = time.Second * 12
 )
 
 func NewVerificationProbes(client Client, verifications []Verification) VerificationProbes {
 	clientLayer := client.ClientLayer()
 	verifProbes := make([]*VerificationProbe, 0)
 	for _, v := range verifications {
-		if v.ClientLayer == clientLayer {
-			requiredClientFound := true
-			if requiredClients, ok := MetricClientTypeRequirements[v.MetricName]; ok {
-				requiredClientFound = false
-				for _, requiredClient := range requiredClients {
-					if requiredClient == client.ClientType() {
-						requiredClientFound = true
-						break
-					}
-				}
-			}
-			if requiredClientFound {
-				dpoints := make(DataPoints)
-				verif := v
-				vProbe := VerificationProbe{
-					Verification:           &verif,
-					Client:                 client,
-					DataPointsPerSlotBlock: dpoints,
-				}
-				verifProbes = append(verifProbes, &vProbe)
+		provider, ok := GetMetricProvider(v.MetricName)
+		if !ok || v.ClientLayer != clientLayer || provider.Layer() != clientLayer {
+			continue
+		}
+		if provider.Supports(client) {
+			dpoints := make(DataPoints)
+			verif := v
+			vProbe := VerificationProbe{
+				Verification:           &verif,
+				Client:                 client,
+				DataPointsPerSlotBlock: dpoints,
 			}
+			verifProbes = append(verifProbes, &vProbe)
 		}
 	}
 	return verifProbes
 }
 
-func (vps *VerificationProbes) AnySyncing() bool {
-	if vps == nil {
-		return false
-	}
-	for _, v := range *vps {
-		if v.IsSyncing {
-			return true
-		}
-	}
-	return false
-}
-
 func (vps *VerificationProbes) AllPassing() bool {
 	if vps == nil {
 		return false
@@ -66,90 +38,32 @@ func (vps *VerificationProbes) AllPassing() bool {
 	return true
 }
 
-func (v *VerificationProbe) Loop(stop <-chan interface{}) {
-	var checkDelay time.Duration
-	if v.Verification.ClientLayer == Beacon {
-		checkDelay = DefaultBeaconCheckDelay
-	} else if v.Verification.ClientLayer == Execution {
-		checkDelay = DefaultExecutionCheckDelay
-	}
-	for {
-		select {
-		case <-stop:
-			return
-		case <-time.After(checkDelay):
-		}
-
-		if v.Verification.PostMerge {
-			ttdBlockSlot, err := v.Client.UpdateGetTTDBlockSlot()
-			if err != nil {
-				log15.Warn("Error getting ttd block/slot", "client", v.Client.ClientType(), "clientID", v.Client.ClientID(), "error", err)
-				continue
-			}
-			if ttdBlockSlot == nil {
-				continue
-			}
-
-			if *ttdBlockSlot > v.PreviousDataPointSlotBlock {
-				v.PreviousDataPointSlotBlock = *ttdBlockSlot
-			}
-		}
-
-		latestBlockSlot, err := v.Client.GetLatestBlockSlotNumber()
-		if err != nil {
-			log15.Warn("Error getting latest block/slot number", "error", err)
-			continue
-		}
-
-		if latestBlockSlot > v.PreviousDataPointSlotBlock {
-			finishedSyncing := false
-			if !v.IsSyncing && (latestBlockSlot-v.PreviousDataPointSlotBlock) > 10 {
-				log15.Info("Syncing data", "type", v.Verification.MetricName)
-				v.IsSyncing = true
-			}
-			currentBlockSlot := v.PreviousDataPointSlotBlock + 1
-			for ; currentBlockSlot <= latestBlockSlot; currentBlockSlot++ {
-				newDataPoint, err := v.Client.GetDataPoint(v.Verification.MetricName, currentBlockSlot)
-				if err != nil {
-					if latestBlockSlot-currentBlockSlot <= 64 {
-						log15.Debug("Error during datapoint fetch, will retry", "client", v.Client.ClientType(), "clientID", v.Client.ClientID(), "datatype", v.Verification.MetricName, "block/slot", currentBlockSlot, "error", err)
-						break
-					}
-					// This data will be considered empty for given block/slot
-					log15.Debug("Unable to fetch datapoint, considered empty", "client", v.Client.ClientType(), "clientID", v.Client.ClientID(), "datatype", v.Verification.MetricName, "block/slot", currentBlockSlot, "error", err)
+// OutcomeDetailer is implemented by clients which can extend the outcome of a verification
+// with details about the collected data
+type OutcomeDetailer interface {
+	OutcomeDetails(metricName MetricName) string
+}
 
-				} else {
-					v.DataPointsPerSlotBlock[currentBlockSlot] = newDataPoint
-				}
-				v.PreviousDataPointSlotBlock = currentBlockSlot
-				if currentBlockSlot == latestBlockSlot {
-					finishedSyncing = true
-				}
-			}
-			if v.IsSyncing && finishedSyncing {
-				log15.Info("Finished syncing data", "datatype", v.Verification.MetricName)
-				v.IsSyncing = false
-				if !v.AllProbesClient.AnySyncing() {
-					log15.Info("Finished syncing all data", "client", v.Client.ClientType(), "clientID", v.Client.ClientID())
-				}
-			}
-		}
-		if !v.IsSyncing {
-			v.CurrentOutcome, _ = v.Verify()
+func (v *VerificationProbe) Verify() (VerificationOutcome, error) {
+	vOut, err := v.verifyAggregatedValue()
+	if err != nil {
+		return vOut, err
+	}
+	if detailer, ok := v.Client.(OutcomeDetailer); ok {
+		if details := detailer.OutcomeDetails(v.Verification.MetricName); details != "" {
+			vOut.Message = fmt.Sprintf("%s, %s", vOut.Message, details)
 		}
 	}
-
+	return vOut, nil
 }
 
-func (v *VerificationProbe) Verify() (VerificationOutcome, error) {
-	if dataLayer, ok := DataTypesPerLayer[v.Verification.ClientLayer]; ok {
-		if dataType, ok := dataLayer[v.Verification.MetricName]; ok {
-			switch dataType {
-			case Uint64:
-				return v.VerifyUint64()
-			case BigInt:
-				return v.VerifyBigInt()
-			}
+func (v *VerificationProbe) verifyAggregatedValue() (VerificationOutcome, error) {
+	if provider, ok := GetMetricProvider(v.Verification.MetricName); ok {
+		switch provider.DataType() {
+		case Uint64:
+			return v.VerifyUint64()
+		case BigInt:
+			return v.VerifyBigInt()
 		}
 	}
 	return VerificationOutcome{}, fmt.Errorf("unknown data: %s", v.Verification.MetricName)
@@ -254,3 +168,11 @@ func (vps VerificationProbes) BeaconVerifications() uint64 {
 	}
 	return retVal
 }
+
+func (vps VerificationProbes) ByMetric() map[MetricName]VerificationProbes {
+	retVal := make(map[MetricName]VerificationProbes)
+	for _, vp := range vps {
+		retVal[vp.Verification.MetricName] = append(retVal[vp.Verification.MetricName], vp)
+	}
+	return retVal
+}